@@ -0,0 +1,358 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// CatalogBundle describes one installable graph bundle: a manifest.json
+// plus a predefined_graphs fragment and the extension folders it needs.
+type CatalogBundle struct {
+	Name              string                 `json:"name"`
+	Version           string                 `json:"version"`
+	Description       string                 `json:"description,omitempty"`
+	RequiredAddons    []string               `json:"required_addons,omitempty"`
+	DefaultProperties map[string]interface{} `json:"default_properties,omitempty"`
+
+	// sourceDir is where the bundle's files (manifest.json, graph.json,
+	// and an optional extensions/ folder) live. Only local sources are
+	// supported for now; a remote index is expected to have already
+	// downloaded and extracted the tarball here.
+	sourceDir string
+}
+
+// CatalogSource lists and materializes installable bundles. The local
+// directory source is the only implementation today; a remote HTTPS index
+// of signed tarballs is expected to implement the same interface by
+// downloading and extracting into a local cache directory before
+// returning.
+type CatalogSource interface {
+	List() ([]CatalogBundle, error)
+	Bundle(name string) (CatalogBundle, error)
+}
+
+// LocalCatalogSource reads bundles from a directory of the form:
+//
+//	<dir>/<bundle-name>/manifest.json
+//	<dir>/<bundle-name>/graph.json
+//	<dir>/<bundle-name>/extensions/<addon-name>/...
+type LocalCatalogSource struct {
+	Dir string
+}
+
+func (src *LocalCatalogSource) List() ([]CatalogBundle, error) {
+	entries, err := os.ReadDir(src.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var bundles []CatalogBundle
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		bundle, err := src.Bundle(e.Name())
+		if err != nil {
+			continue
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles, nil
+}
+
+func (src *LocalCatalogSource) Bundle(name string) (CatalogBundle, error) {
+	dir := filepath.Join(src.Dir, name)
+	if !isPathSafe(dir, src.Dir) {
+		return CatalogBundle{}, fmt.Errorf("catalog: bundle name %q escapes catalog dir", name)
+	}
+
+	manifestPath := filepath.Join(dir, "manifest.json")
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return CatalogBundle{}, fmt.Errorf("catalog: read manifest for %q: %w", name, err)
+	}
+
+	var bundle CatalogBundle
+	if err := json.Unmarshal(content, &bundle); err != nil {
+		return CatalogBundle{}, fmt.Errorf("catalog: parse manifest for %q: %w", name, err)
+	}
+	bundle.Name = name
+	bundle.sourceDir = dir
+
+	return bundle, nil
+}
+
+// graphFragment loads the bundle's predefined_graphs entry (graph.json).
+func (b CatalogBundle) graphFragment() (map[string]interface{}, error) {
+	content, err := os.ReadFile(filepath.Join(b.sourceDir, "graph.json"))
+	if err != nil {
+		return nil, fmt.Errorf("catalog: read graph.json for %q: %w", b.Name, err)
+	}
+
+	var graph map[string]interface{}
+	if err := json.Unmarshal(content, &graph); err != nil {
+		return nil, fmt.Errorf("catalog: parse graph.json for %q: %w", b.Name, err)
+	}
+	return graph, nil
+}
+
+// InstallResult is what POST /catalog/graphs/{name}/install returns.
+// PropertyJsonDiff holds the complete updated property.json as written
+// (or as it would be written, when DryRun is set); it is not a line diff
+// against the previous file.
+type InstallResult struct {
+	BundleName       string   `json:"bundle_name"`
+	DryRun           bool     `json:"dry_run"`
+	AddedGraphName   string   `json:"added_graph_name"`
+	InstalledAddons  []string `json:"installed_addons"`
+	PropertyJsonDiff string   `json:"property_json_diff,omitempty"`
+}
+
+// installBundle merges bundle's graph fragment into the property.json at
+// propertyJsonPath and copies any missing extensions into extensionsDir.
+// When dryRun is true, no files are modified; the would-be diff is still
+// computed and returned.
+func installBundle(bundle CatalogBundle, propertyJsonPath, extensionsDir string, dryRun bool) (*InstallResult, error) {
+	graphFragment, err := bundle.graphFragment()
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := os.ReadFile(propertyJsonPath)
+	if err != nil {
+		return nil, fmt.Errorf("catalog: read property.json: %w", err)
+	}
+
+	var propertyJson map[string]interface{}
+	if err := json.Unmarshal(before, &propertyJson); err != nil {
+		return nil, fmt.Errorf("catalog: parse property.json: %w", err)
+	}
+
+	tenSection, ok := propertyJson["ten"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("catalog: invalid property.json: missing ten section")
+	}
+
+	predefinedGraphs, _ := tenSection["predefined_graphs"].([]interface{})
+	for _, g := range predefinedGraphs {
+		if gm, ok := g.(map[string]interface{}); ok && gm["name"] == graphFragment["name"] {
+			return nil, fmt.Errorf("catalog: graph name %q already exists in property.json", graphFragment["name"])
+		}
+	}
+
+	if collision, ok := firstCollidingNodeName(predefinedGraphs, graphFragment); ok {
+		return nil, fmt.Errorf("catalog: node name %q in bundle %q collides with a node already present in an installed graph", collision, bundle.Name)
+	}
+
+	tenSection["predefined_graphs"] = append(predefinedGraphs, graphFragment)
+
+	after, err := json.MarshalIndent(propertyJson, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("catalog: marshal updated property.json: %w", err)
+	}
+
+	graphName, _ := graphFragment["name"].(string)
+	result := &InstallResult{
+		BundleName:       bundle.Name,
+		DryRun:           dryRun,
+		AddedGraphName:   graphName,
+		PropertyJsonDiff: string(after),
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	if err := atomicWriteWithBackup(propertyJsonPath, after); err != nil {
+		return nil, err
+	}
+
+	installed, err := copyMissingExtensions(bundle, extensionsDir)
+	if err != nil {
+		return nil, err
+	}
+	result.InstalledAddons = installed
+
+	return result, nil
+}
+
+// graphNodeNames returns the "name" of every node in graph's "graph.nodes"
+// array, tolerating any of the lookups failing (an empty/malformed graph
+// just contributes no names).
+func graphNodeNames(graph map[string]interface{}) []string {
+	graphData, ok := graph["graph"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	nodes, ok := graphData["nodes"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for _, node := range nodes {
+		nodeMap, ok := node.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := nodeMap["name"].(string); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// firstCollidingNodeName reports the first node name in newGraph that is
+// already used by a node in one of existingGraphs, so installBundle can
+// refuse to merge a bundle whose extensions would shadow an already
+// running node of the same name.
+func firstCollidingNodeName(existingGraphs []interface{}, newGraph map[string]interface{}) (string, bool) {
+	existing := make(map[string]bool)
+	for _, g := range existingGraphs {
+		gm, ok := g.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, name := range graphNodeNames(gm) {
+			existing[name] = true
+		}
+	}
+
+	for _, name := range graphNodeNames(newGraph) {
+		if existing[name] {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// atomicWriteWithBackup writes content to path via a temp file + rename,
+// after copying the existing file to path+".bak" so a failed install can
+// be manually rolled back.
+func atomicWriteWithBackup(path string, content []byte) error {
+	if existing, err := os.ReadFile(path); err == nil {
+		if err := os.WriteFile(path+".bak", existing, 0o644); err != nil {
+			return fmt.Errorf("catalog: backup property.json: %w", err)
+		}
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return fmt.Errorf("catalog: write temp property.json: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("catalog: rename temp property.json: %w", err)
+	}
+	return nil
+}
+
+// copyMissingExtensions copies any extension folder under the bundle's
+// extensions/ directory into extensionsDir that isn't already there.
+func copyMissingExtensions(bundle CatalogBundle, extensionsDir string) ([]string, error) {
+	src := filepath.Join(bundle.sourceDir, "extensions")
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("catalog: read bundle extensions: %w", err)
+	}
+
+	var installed []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dst := filepath.Join(extensionsDir, e.Name())
+		if _, err := os.Stat(dst); err == nil {
+			continue // already present
+		}
+
+		if err := copyDir(filepath.Join(src, e.Name()), dst); err != nil {
+			return installed, fmt.Errorf("catalog: install addon %q: %w", e.Name(), err)
+		}
+		installed = append(installed, e.Name())
+	}
+	return installed, nil
+}
+
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, content, 0o644)
+	})
+}
+
+// uninstallBundle removes a previously installed graph's predefined_graphs
+// entry from property.json. Installed extension folders are left in place
+// since other graphs may depend on them.
+func uninstallBundle(graphName, propertyJsonPath string) error {
+	content, err := os.ReadFile(propertyJsonPath)
+	if err != nil {
+		return fmt.Errorf("catalog: read property.json: %w", err)
+	}
+
+	var propertyJson map[string]interface{}
+	if err := json.Unmarshal(content, &propertyJson); err != nil {
+		return fmt.Errorf("catalog: parse property.json: %w", err)
+	}
+
+	tenSection, ok := propertyJson["ten"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("catalog: invalid property.json: missing ten section")
+	}
+
+	predefinedGraphs, _ := tenSection["predefined_graphs"].([]interface{})
+	var kept []interface{}
+	found := false
+	for _, g := range predefinedGraphs {
+		if gm, ok := g.(map[string]interface{}); ok && gm["name"] == graphName {
+			found = true
+			continue
+		}
+		kept = append(kept, g)
+	}
+	if !found {
+		return fmt.Errorf("catalog: graph %q not found in property.json", graphName)
+	}
+	tenSection["predefined_graphs"] = kept
+
+	after, err := json.MarshalIndent(propertyJson, "", "  ")
+	if err != nil {
+		return fmt.Errorf("catalog: marshal updated property.json: %w", err)
+	}
+
+	return atomicWriteWithBackup(propertyJsonPath, after)
+}
@@ -0,0 +1,110 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+// catalogSource returns the configured catalog source, or nil if the
+// server wasn't given a CatalogDir.
+func (s *HttpServer) catalogSource() CatalogSource {
+	if s.config.CatalogDir == "" {
+		return nil
+	}
+	return &LocalCatalogSource{Dir: s.config.CatalogDir}
+}
+
+// handlerCatalogGraphsList returns every bundle the configured catalog
+// source currently offers.
+func (s *HttpServer) handlerCatalogGraphsList(c *gin.Context) {
+	src := s.catalogSource()
+	if src == nil {
+		s.output(c, codeSuccess, []CatalogBundle{})
+		return
+	}
+
+	bundles, err := src.List()
+	if err != nil {
+		s.output(c, codeErrReadDirectoryFailed, http.StatusInternalServerError)
+		return
+	}
+
+	s.output(c, codeSuccess, bundles)
+}
+
+// catalogBundleExists reports whether name matches a bundle actually
+// returned by src.List(), so an install request can't reach Bundle() (and
+// the filesystem join it performs) with an arbitrary, unlisted name.
+func (s *HttpServer) catalogBundleExists(src CatalogSource, name string) bool {
+	bundles, err := src.List()
+	if err != nil {
+		return false
+	}
+	for _, b := range bundles {
+		if b.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// handlerCatalogGraphInstall merges a bundle's predefined_graphs entry
+// into property.json (with a backup + atomic rename) and pulls any
+// missing extensions into the agents/ten_packages/extension directory. A
+// `?dry_run=true` query param returns the diff without writing anything.
+func (s *HttpServer) handlerCatalogGraphInstall(c *gin.Context) {
+	src := s.catalogSource()
+	if src == nil {
+		s.output(c, codeErrParamsInvalid, http.StatusBadRequest)
+		return
+	}
+
+	name := c.Param("name")
+	if !s.catalogBundleExists(src, name) {
+		s.output(c, codeErrChannelNotExisted, http.StatusNotFound)
+		return
+	}
+
+	bundle, err := src.Bundle(name)
+	if err != nil {
+		s.output(c, codeErrChannelNotExisted, http.StatusNotFound)
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+
+	propertyJsonPath := filepath.Join(s.config.TenappDir, "property.json")
+	extensionsDir := "./agents/ten_packages/extension"
+
+	result, err := installBundle(bundle, propertyJsonPath, extensionsDir, dryRun)
+	if err != nil {
+		s.output(c, codeErrProcessPropertyFailed, http.StatusBadRequest)
+		return
+	}
+
+	s.output(c, codeSuccess, result)
+}
+
+// handlerCatalogGraphUninstall removes a bundle's predefined_graphs entry
+// from property.json. Installed extension folders are left in place since
+// other graphs may still reference them.
+func (s *HttpServer) handlerCatalogGraphUninstall(c *gin.Context) {
+	name := c.Param("name")
+	propertyJsonPath := filepath.Join(s.config.TenappDir, "property.json")
+
+	if err := uninstallBundle(name, propertyJsonPath); err != nil {
+		s.output(c, codeErrProcessPropertyFailed, http.StatusBadRequest)
+		return
+	}
+
+	s.output(c, codeSuccess, map[string]any{"name": name})
+}
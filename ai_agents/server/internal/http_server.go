@@ -8,14 +8,16 @@
 package internal
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
@@ -23,10 +25,17 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/gogf/gf/crypto/gmd5"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type HttpServer struct {
-	config *HttpServerConfig
+	config          *HttpServerConfig
+	liveState       *LiveStateStore
+	workerPool      *WorkerPool
+	secrets         *secretResolverRegistry
+	store           PropertyStore
+	propertyWatcher *PropertyWatcher
+	remoteFetch     *remoteFetchCache
 }
 
 type HttpServerConfig struct {
@@ -39,6 +48,38 @@ type HttpServerConfig struct {
 	WorkersMax               int
 	WorkerQuitTimeoutSeconds int
 	TenappDir                string
+
+	// CatalogDir is a local directory of installable graph bundles
+	// (<CatalogDir>/<name>/manifest.json, graph.json, extensions/...).
+	// Empty disables the /catalog/graphs endpoints.
+	CatalogDir string
+
+	// PropertyStore backs where processProperty writes property.json and
+	// log output. Nil defaults to a LocalPropertyStore rooted at LogPath.
+	PropertyStore PropertyStore
+
+	// HMACSecret, when non-empty, requires mutating routes to carry a
+	// valid X-TEN-Signature / X-TEN-Timestamp pair (see requireHMACAuth).
+	// Empty leaves those routes unauthenticated.
+	HMACSecret string
+
+	// HMACClockSkewSeconds bounds how far X-TEN-Timestamp may drift from
+	// the server's clock. Zero means defaultHMACClockSkewSeconds.
+	HMACClockSkewSeconds int
+
+	// RemoteFetch bounds StartReq.GraphURL / StartReq.PropertyOverlayURL
+	// fetches. The zero value allows any https host.
+	RemoteFetch RemoteFetchConfig
+
+	// PropertyBaseDir roots property.json discovery: resolvePropertyJson
+	// walks upward from StartReq.WorkingDir (or this dir itself when
+	// empty) looking for property.json, never searching above this dir.
+	// Empty means TenappDir, preserving the old fixed-location behavior.
+	PropertyBaseDir string
+
+	// Env names the deployment environment (e.g. "staging", "prod") used
+	// to pick up a property.<env>.json overlay. Empty skips that layer.
+	Env string
 }
 
 type PingReq struct {
@@ -57,11 +98,30 @@ type StartReq struct {
 	Properties           map[string]map[string]interface{} `json:"properties,omitempty"`
 	QuitTimeoutSeconds   int                               `json:"timeout,omitempty"`
 	TenappDir            string                            `json:"tenapp_dir,omitempty"` // IGNORED for security - always uses launch tenapp_dir
+	Deadline             string                            `json:"deadline,omitempty"`   // e.g. "30s"; also settable via X-Request-Timeout
+
+	// GraphURL, if set, fetches the predefined_graphs entry for GraphName
+	// from this https URL instead of requiring it to already be present
+	// in the local property.json. See RemoteFetchConfig for the SSRF
+	// safeguards applied to the fetch.
+	GraphURL string `json:"graph_url,omitempty"`
+
+	// PropertyOverlayURL, if set, fetches a Properties-shaped JSON object
+	// from this https URL and merges it over Properties before the
+	// secret-substitution pass.
+	PropertyOverlayURL string `json:"property_overlay_url,omitempty"`
+
+	// WorkingDir, if set, is resolved relative to
+	// HttpServerConfig.PropertyBaseDir and is where property.json
+	// discovery starts walking upward from. Empty starts at
+	// PropertyBaseDir itself.
+	WorkingDir string `json:"working_dir,omitempty"`
 }
 
 type StopReq struct {
 	RequestId   string `json:"request_id,omitempty"`
 	ChannelName string `json:"channel_name,omitempty"`
+	Deadline    string `json:"deadline,omitempty"`
 }
 
 type GenerateTokenReq struct {
@@ -83,10 +143,32 @@ type VectorDocumentUpload struct {
 	File        *multipart.FileHeader `form:"file" binding:"required"`
 }
 
-func NewHttpServer(httpServerConfig *HttpServerConfig) *HttpServer {
-	return &HttpServer{
-		config: httpServerConfig,
+func NewHttpServer(httpServerConfig *HttpServerConfig, opts ...HttpServerOption) *HttpServer {
+	store := httpServerConfig.PropertyStore
+	if store == nil {
+		store = NewLocalPropertyStore(httpServerConfig.LogPath)
+	}
+
+	s := &HttpServer{
+		config:      httpServerConfig,
+		liveState:   NewLiveStateStore(),
+		workerPool:  NewWorkerPool(time.Duration(httpServerConfig.WorkerQuitTimeoutSeconds) * time.Second),
+		secrets:     newSecretResolverRegistry(),
+		store:       store,
+		remoteFetch: newRemoteFetchCache(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.propertyWatcher = newPropertyWatcher(s)
+	return s
+}
+
+// Drain performs the worker pool's bounded graceful drain and is intended
+// to be called from the process's shutdown signal handler in place of the
+// previous CleanWorkers() kill.
+func (s *HttpServer) Drain(ctx context.Context) {
+	s.workerPool.Drain(ctx)
 }
 
 func (s *HttpServer) handlerHealth(c *gin.Context) {
@@ -94,6 +176,18 @@ func (s *HttpServer) handlerHealth(c *gin.Context) {
 	s.output(c, codeOk, nil)
 }
 
+// handlerReadyz reports whether the pool is accepting new sessions, unlike
+// /healthz which only reports process liveness. Orchestrators should use
+// this to drive rolling restarts: once Drain starts, readiness flips to
+// false while liveness stays true until the process actually exits.
+func (s *HttpServer) handlerReadyz(c *gin.Context) {
+	if !s.workerPool.Ready() {
+		s.output(c, codeErrWorkersLimit, http.StatusServiceUnavailable)
+		return
+	}
+	s.output(c, codeOk, nil)
+}
+
 func (s *HttpServer) handlerList(c *gin.Context) {
 	slog.Info("handlerList start", logTag)
 	// Create a slice of maps to hold the filtered data
@@ -160,6 +254,63 @@ func (s *HttpServer) handleGraphs(c *gin.Context) {
 	s.output(c, codeSuccess, graphs)
 }
 
+// handleGraphsLive returns the live-state snapshot of every graph the
+// reporter currently knows about, including dynamically started subgraphs.
+// This is distinct from GET /graphs, which only lists the predefined
+// graphs declared in property.json.
+func (s *HttpServer) handleGraphsLive(c *gin.Context) {
+	s.output(c, codeSuccess, s.liveState.Snapshot())
+}
+
+// handleGraphLiveState returns the live-state snapshot for a single graph.
+func (s *HttpServer) handleGraphLiveState(c *gin.Context) {
+	graphID := c.Param("id")
+
+	snapshot, ok := s.liveState.Graph(graphID)
+	if !ok {
+		s.output(c, codeErrChannelNotExisted, http.StatusNotFound)
+		return
+	}
+
+	s.output(c, codeSuccess, snapshot)
+}
+
+// handleGraphLiveEvents streams cmd/data/state/error events for a graph as
+// Server-Sent Events, so a controller can watch message flow without
+// polling.
+func (s *HttpServer) handleGraphLiveEvents(c *gin.Context) {
+	graphID := c.Param("id")
+
+	if _, ok := s.liveState.Graph(graphID); !ok {
+		s.output(c, codeErrChannelNotExisted, http.StatusNotFound)
+		return
+	}
+
+	events, unsubscribe := s.liveState.Subscribe(graphID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return false
+			}
+			payload, err := json.Marshal(ev)
+			if err != nil {
+				return true
+			}
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
 func (s *HttpServer) handleAddonDefaultProperties(c *gin.Context) {
 	// Get the base directory path
 	baseDir := "./agents/ten_packages/extension"
@@ -237,6 +388,12 @@ func (s *HttpServer) handlerStart(c *gin.Context) {
 
 	slog.Info("handlerStart start", "workersRunning", workersRunning, logTag)
 
+	if !s.workerPool.Accepting() {
+		slog.Warn("handlerStart rejected, pool is draining", logTag)
+		s.output(c, codeErrWorkersLimit, http.StatusServiceUnavailable)
+		return
+	}
+
 	var req StartReq
 
 	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
@@ -291,9 +448,14 @@ func (s *HttpServer) handlerStart(c *gin.Context) {
 	}
 	slog.Info("Using launch tenapp_dir", "requestId", req.RequestId, "tenappDir", tenappDir, logTag)
 
-	propertyJsonFile, logFile, err := s.processProperty(&req, tenappDir)
+	propertyJsonFile, logFile, propertyJsonName, resolvedPropertyJson, secretSources, resolvedLayers, err := s.processProperty(&req, tenappDir)
 	if err != nil {
-		slog.Error("handlerStart process property", "channelName", req.ChannelName, "requestId", req.RequestId, logTag)
+		slog.Error("handlerStart process property", "err", err, "channelName", req.ChannelName, "requestId", req.RequestId, logTag)
+		var secretErr *SecretResolutionError
+		if errors.As(err, &secretErr) {
+			s.output(c, codeErrProcessPropertyFailed, secretErr.Failures, http.StatusBadRequest)
+			return
+		}
 		s.output(c, codeErrProcessPropertyFailed, http.StatusInternalServerError)
 		return
 	}
@@ -308,15 +470,37 @@ func (s *HttpServer) handlerStart(c *gin.Context) {
 		worker.QuitTimeoutSeconds = s.config.WorkerQuitTimeoutSeconds
 	}
 
-	if err := worker.start(&req); err != nil {
-		slog.Error("handlerStart start worker failed", "err", err, "requestId", req.RequestId, logTag)
-		s.output(c, codeErrStartWorkerFailed, http.StatusInternalServerError)
-		return
-	}
+	// Reserve the channel name up front so a cancelled/timed-out start can
+	// be rolled back by runWithDeadline without racing a concurrent
+	// handlerStart for the same channel.
 	workers.SetIfNotExist(req.ChannelName, worker)
 
-	slog.Info("handlerStart end", "workersRunning", workers.Size(), "worker", worker, "requestId", req.RequestId, logTag)
-	s.output(c, codeSuccess, nil)
+	ctx, cancel := contextFromRequest(c, req.Deadline)
+	op := newOperation("start", req.ChannelName, req.RequestId, cancel)
+
+	go func() {
+		defer cancel()
+		op.setRunning()
+
+		runWithDeadline(ctx, req.ChannelName, op, func() error {
+			if err := worker.startCtx(ctx, &req); err != nil {
+				slog.Error("handlerStart start worker failed", "err", err, "requestId", req.RequestId, logTag)
+				s.liveState.RecordEvent(GraphEvent{GraphID: req.ChannelName, Kind: "error", Detail: err.Error(), Ts: time.Now()})
+				return err
+			}
+			s.propertyWatcher.Track(req.ChannelName, propertyJsonName, worker, resolvedPropertyJson, secretSources)
+			s.recordGraphStarted(req.ChannelName, worker.CreateTs, resolvedPropertyJson)
+			slog.Info("handlerStart end", "workersRunning", workers.Size(), "worker", worker, "requestId", req.RequestId, logTag)
+			return nil
+		})
+	}()
+
+	s.output(c, codeSuccess, map[string]any{
+		"operation_id":    op.ID,
+		"status":          op.Status,
+		"resource_url":    op.ResourceURL,
+		"property_layers": resolvedLayers,
+	}, http.StatusAccepted)
 }
 
 func (s *HttpServer) handlerStop(c *gin.Context) {
@@ -343,14 +527,136 @@ func (s *HttpServer) handlerStop(c *gin.Context) {
 	}
 
 	worker := workers.Get(req.ChannelName).(*Worker)
-	if err := worker.stop(req.RequestId, req.ChannelName); err != nil {
-		slog.Error("handlerStop kill app failed", "err", err, "worker", workers.Get(req.ChannelName), "requestId", req.RequestId, logTag)
-		s.output(c, codeErrStopWorkerFailed, http.StatusInternalServerError)
+
+	ctx, cancel := contextFromRequest(c, req.Deadline)
+	op := newOperation("stop", req.ChannelName, req.RequestId, cancel)
+
+	go func() {
+		defer cancel()
+		op.setRunning()
+
+		runWithDeadline(ctx, req.ChannelName, op, func() error {
+			if err := worker.stopCtx(ctx, req.RequestId, req.ChannelName); err != nil {
+				slog.Error("handlerStop kill app failed", "err", err, "worker", workers.Get(req.ChannelName), "requestId", req.RequestId, logTag)
+				s.liveState.RecordEvent(GraphEvent{GraphID: req.ChannelName, Kind: "error", Detail: err.Error(), Ts: time.Now()})
+				return err
+			}
+			s.propertyWatcher.Forget(req.ChannelName)
+			s.recordGraphStopped(req.ChannelName)
+			slog.Info("handlerStop end", "requestId", req.RequestId, logTag)
+			return nil
+		})
+	}()
+
+	s.output(c, codeSuccess, map[string]any{
+		"operation_id": op.ID,
+		"status":       op.Status,
+		"resource_url": op.ResourceURL,
+	}, http.StatusAccepted)
+}
+
+// handlerChannelProperties reports which ${scheme:key} references are
+// currently in effect for a running channel's property.json, for
+// diagnosing secret provenance without exposing property.json itself.
+// The resolved secret values themselves are never included in the
+// response, even for authorized callers -- only their reference and
+// resolution metadata.
+func (s *HttpServer) handlerChannelProperties(c *gin.Context) {
+	channelName := c.Param("name")
+
+	if !workers.Contains(channelName) {
+		slog.Error("handlerChannelProperties channel not existed", "channelName", channelName, logTag)
+		s.output(c, codeErrChannelNotExisted, http.StatusBadRequest)
 		return
 	}
 
-	slog.Info("handlerStop end", "requestId", req.RequestId, logTag)
-	s.output(c, codeSuccess, nil)
+	sources, ok := s.propertyWatcher.Snapshot(channelName)
+	if !ok {
+		s.output(c, codeErrChannelNotExisted, http.StatusBadRequest)
+		return
+	}
+
+	s.output(c, codeSuccess, map[string]any{
+		"channel_name": channelName,
+		"properties":   redactResolvedValueSources(sources),
+	})
+}
+
+// redactedValueSource is resolvedValueSource with Value omitted, for
+// responses returned to HTTP callers.
+type redactedValueSource struct {
+	ExtensionName string `json:"extension_name"`
+	Property      string `json:"property"`
+	SecretRef     `json:"ref"`
+	ResolvedAt    time.Time `json:"resolved_at"`
+}
+
+func redactResolvedValueSources(sources []resolvedValueSource) []redactedValueSource {
+	redacted := make([]redactedValueSource, len(sources))
+	for i, src := range sources {
+		redacted[i] = redactedValueSource{
+			ExtensionName: src.ExtensionName,
+			Property:      src.Property,
+			SecretRef:     src.SecretRef,
+			ResolvedAt:    src.ResolvedAt,
+		}
+	}
+	return redacted
+}
+
+// handlerChannelReload re-resolves a running channel's secret references
+// on demand, rewriting property.json and notifying the worker of any
+// value that changed, without requiring a stop/start cycle.
+func (s *HttpServer) handlerChannelReload(c *gin.Context) {
+	channelName := c.Param("name")
+
+	if !workers.Contains(channelName) {
+		slog.Error("handlerChannelReload channel not existed", "channelName", channelName, logTag)
+		s.output(c, codeErrChannelNotExisted, http.StatusBadRequest)
+		return
+	}
+
+	changed, failures := s.propertyWatcher.Reload(channelName)
+	if len(failures) > 0 {
+		slog.Error("handlerChannelReload unresolved references", "channelName", channelName, "failures", failures, logTag)
+		s.output(c, codeErrProcessPropertyFailed, failures, http.StatusBadRequest)
+		return
+	}
+
+	slog.Info("handlerChannelReload end", "channelName", channelName, "changed", len(changed), logTag)
+	s.output(c, codeSuccess, map[string]any{
+		"channel_name": channelName,
+		"changed":      changed,
+	})
+}
+
+// handlerPropertyResolve resolves the layered property.json chain
+// (base file plus any property.<env>/<channel>/local.json overlays) for
+// debugging, without filtering to a graph or starting a worker.
+func (s *HttpServer) handlerPropertyResolve(c *gin.Context) {
+	channelName := c.Query("channel")
+	workingDirParam := c.Query("working_dir")
+
+	baseDir := s.config.PropertyBaseDir
+	if baseDir == "" {
+		baseDir = s.config.TenappDir
+	}
+	workingDir := baseDir
+	if workingDirParam != "" {
+		workingDir = filepath.Join(baseDir, workingDirParam)
+	}
+
+	merged, layers, err := s.resolvePropertyJson(baseDir, workingDir, channelName)
+	if err != nil {
+		slog.Error("handlerPropertyResolve failed", "err", err, "channelName", channelName, logTag)
+		s.output(c, codeErrProcessPropertyFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.output(c, codeSuccess, map[string]any{
+		"property": merged,
+		"layers":   layers,
+	})
 }
 
 func (s *HttpServer) handlerGenerateToken(c *gin.Context) {
@@ -421,24 +727,40 @@ func (s *HttpServer) handlerVectorDocumentUpdate(c *gin.Context) {
 
 	// update worker
 	worker := workers.Get(req.ChannelName).(*Worker)
-	err := worker.update(&WorkerUpdateReq{
-		RequestId:   req.RequestId,
-		ChannelName: req.ChannelName,
-		Collection:  req.Collection,
-		FileName:    req.FileName,
-		Ten: &WorkerUpdateReqTen{
-			Name: "update_querying_collection",
-			Type: "cmd",
-		},
-	})
-	if err != nil {
-		slog.Error("handlerVectorDocumentUpdate update worker failed", "err", err, "channelName", req.ChannelName, "Collection", req.Collection, "FileName", req.FileName, "requestId", req.RequestId, logTag)
-		s.output(c, codeErrUpdateWorkerFailed, http.StatusBadRequest)
-		return
-	}
 
-	slog.Info("handlerVectorDocumentUpdate end", "channelName", req.ChannelName, "Collection", req.Collection, "FileName", req.FileName, "requestId", req.RequestId, logTag)
-	s.output(c, codeSuccess, map[string]any{"channel_name": req.ChannelName})
+	ctx, cancel := contextFromRequest(c, "")
+	op := newOperation("vector_document_update", req.ChannelName, req.RequestId, cancel)
+
+	go func() {
+		defer cancel()
+		op.setRunning()
+
+		err := worker.updateCtx(ctx, &WorkerUpdateReq{
+			RequestId:   req.RequestId,
+			ChannelName: req.ChannelName,
+			Collection:  req.Collection,
+			FileName:    req.FileName,
+			Ten: &WorkerUpdateReqTen{
+				Name: "update_querying_collection",
+				Type: "cmd",
+			},
+		})
+		if err != nil {
+			slog.Error("handlerVectorDocumentUpdate update worker failed", "err", err, "channelName", req.ChannelName, "Collection", req.Collection, "FileName", req.FileName, "requestId", req.RequestId, logTag)
+			op.finish(OperationFailure, err)
+			return
+		}
+
+		slog.Info("handlerVectorDocumentUpdate end", "channelName", req.ChannelName, "Collection", req.Collection, "FileName", req.FileName, "requestId", req.RequestId, logTag)
+		op.finish(OperationSuccess, nil)
+	}()
+
+	s.output(c, codeSuccess, map[string]any{
+		"channel_name": req.ChannelName,
+		"operation_id": op.ID,
+		"status":       op.Status,
+		"resource_url": op.ResourceURL,
+	}, http.StatusAccepted)
 }
 
 func (s *HttpServer) handlerVectorDocumentUpload(c *gin.Context) {
@@ -480,25 +802,44 @@ func (s *HttpServer) handlerVectorDocumentUpload(c *gin.Context) {
 
 	// update worker
 	worker := workers.Get(req.ChannelName).(*Worker)
-	err = worker.update(&WorkerUpdateReq{
-		RequestId:   req.RequestId,
-		ChannelName: req.ChannelName,
-		Collection:  collection,
-		FileName:    fileName,
-		Path:        uploadFile,
-		Ten: &WorkerUpdateReqTen{
-			Name: "file_chunk",
-			Type: "cmd",
-		},
-	})
-	if err != nil {
-		slog.Error("handlerVectorDocumentUpload update worker failed", "err", err, "channelName", req.ChannelName, "requestId", req.RequestId, logTag)
-		s.output(c, codeErrUpdateWorkerFailed, http.StatusBadRequest)
-		return
-	}
 
-	slog.Info("handlerVectorDocumentUpload end", "channelName", req.ChannelName, "collection", collection, "uploadFile", uploadFile, "requestId", req.RequestId, logTag)
-	s.output(c, codeSuccess, map[string]any{"channel_name": req.ChannelName, "collection": collection, "file_name": fileName})
+	ctx, cancel := contextFromRequest(c, "")
+	op := newOperation("vector_document_upload", req.ChannelName, req.RequestId, cancel)
+
+	go func() {
+		defer cancel()
+		op.setRunning()
+		op.Publish(OperationEvent{Phase: "uploaded", BytesProcessed: file.Size, Ts: time.Now()})
+
+		err := worker.updateCtx(ctx, &WorkerUpdateReq{
+			RequestId:   req.RequestId,
+			ChannelName: req.ChannelName,
+			Collection:  collection,
+			FileName:    fileName,
+			Path:        uploadFile,
+			Ten: &WorkerUpdateReqTen{
+				Name: "file_chunk",
+				Type: "cmd",
+			},
+		})
+		if err != nil {
+			slog.Error("handlerVectorDocumentUpload update worker failed", "err", err, "channelName", req.ChannelName, "requestId", req.RequestId, logTag)
+			op.finish(OperationFailure, err)
+			return
+		}
+
+		slog.Info("handlerVectorDocumentUpload end", "channelName", req.ChannelName, "collection", collection, "uploadFile", uploadFile, "requestId", req.RequestId, logTag)
+		op.finish(OperationSuccess, nil)
+	}()
+
+	s.output(c, codeSuccess, map[string]any{
+		"channel_name": req.ChannelName,
+		"collection":   collection,
+		"file_name":    fileName,
+		"operation_id": op.ID,
+		"status":       op.Status,
+		"resource_url": op.ResourceURL,
+	}, http.StatusAccepted)
 }
 
 func (s *HttpServer) output(c *gin.Context, code *Code, data any, httpStatus ...int) {
@@ -531,27 +872,29 @@ func mergeProperties(original, newProps map[string]interface{}) map[string]inter
 	return original
 }
 
-func (s *HttpServer) processProperty(req *StartReq, tenappDir string) (propertyJsonFile string, logFile string, err error) {
+func (s *HttpServer) processProperty(req *StartReq, tenappDir string) (propertyJsonFile string, logFile string, propertyJsonName string, resolvedPropertyJson map[string]interface{}, secretSources []resolvedValueSource, resolvedLayers []string, err error) {
 	// Debug logging
 	slog.Info("processProperty called", "requestId", req.RequestId, "tenappDir", tenappDir, "logPath", s.config.LogPath, logTag)
 
-	// Build property.json path based on tenapp_dir
-	propertyJsonPath := filepath.Join(tenappDir, "property.json")
-	slog.Info("Reading property.json from", "requestId", req.RequestId, "propertyJsonPath", propertyJsonPath, logTag)
-
-	content, err := os.ReadFile(propertyJsonPath)
-	if err != nil {
-		slog.Error("handlerStart read property.json failed", "err", err, "propertyJsonPath", propertyJsonPath, "requestId", req.RequestId, logTag)
-		return
+	// Resolve property.json by walking upward from WorkingDir (relative to
+	// PropertyBaseDir) and layering property.<env>.json,
+	// property.<channel>.json, and property.local.json on top, in that
+	// order, when present alongside the base file.
+	baseDir := s.config.PropertyBaseDir
+	if baseDir == "" {
+		baseDir = tenappDir
+	}
+	workingDir := baseDir
+	if req.WorkingDir != "" {
+		workingDir = filepath.Join(baseDir, req.WorkingDir)
 	}
 
-	// Unmarshal the JSON content into a map
-	var propertyJson map[string]interface{}
-	err = json.Unmarshal(content, &propertyJson)
+	propertyJson, resolvedLayers, err := s.resolvePropertyJson(baseDir, workingDir, req.ChannelName)
 	if err != nil {
-		slog.Error("handlerStart unmarshal property.json failed", "err", err, "requestId", req.RequestId, logTag)
+		slog.Error("processProperty resolve property.json failed", "err", err, "baseDir", baseDir, "workingDir", workingDir, "requestId", req.RequestId, logTag)
 		return
 	}
+	slog.Info("Resolved property.json layers", "requestId", req.RequestId, "layers", resolvedLayers, logTag)
 
 	// Get graph name
 	graphName := req.GraphName
@@ -578,25 +921,36 @@ func (s *HttpServer) processProperty(req *StartReq, tenappDir string) (propertyJ
 		return
 	}
 
-	predefinedGraphs, ok := tenSection["predefined_graphs"].([]interface{})
-	if !ok {
-		slog.Error("Invalid format: predefined_graphs missing or not an array", "requestId", req.RequestId, logTag)
-		return
-	}
-
-	// Filter the graph with the matching name
+	// Filter the graph with the matching name, or fetch it remotely if
+	// GraphURL was supplied instead of relying on the local property.json.
 	var newGraphs []interface{}
-	for _, graph := range predefinedGraphs {
-		graphMap, ok := graph.(map[string]interface{})
-		if ok && graphMap["name"] == graphName {
-			newGraphs = append(newGraphs, graph)
+	if req.GraphURL != "" {
+		remoteGraph, ferr := s.fetchRemoteGraph(req.GraphURL, req.RequestId)
+		if ferr != nil {
+			slog.Error("processProperty fetch remote graph failed", "err", ferr, "graphUrl", req.GraphURL, "requestId", req.RequestId, logTag)
+			err = fmt.Errorf("fetch remote graph: %w", ferr)
+			return
+		}
+		newGraphs = []interface{}{remoteGraph}
+	} else {
+		predefinedGraphs, ok := tenSection["predefined_graphs"].([]interface{})
+		if !ok {
+			slog.Error("Invalid format: predefined_graphs missing or not an array", "requestId", req.RequestId, logTag)
+			return
 		}
-	}
 
-	if len(newGraphs) == 0 {
-		slog.Error("handlerStart graph not found", "graph", graphName, "requestId", req.RequestId, logTag)
-		err = fmt.Errorf("graph not found")
-		return
+		for _, graph := range predefinedGraphs {
+			graphMap, ok := graph.(map[string]interface{})
+			if ok && graphMap["name"] == graphName {
+				newGraphs = append(newGraphs, graph)
+			}
+		}
+
+		if len(newGraphs) == 0 {
+			slog.Error("handlerStart graph not found", "graph", graphName, "requestId", req.RequestId, logTag)
+			err = fmt.Errorf("graph not found")
+			return
+		}
 	}
 
 	// Replace the predefined_graphs array with the filtered array
@@ -608,6 +962,27 @@ func (s *HttpServer) processProperty(req *StartReq, tenappDir string) (propertyJ
 		graphMap["auto_start"] = true
 	}
 
+	// Merge a remote property overlay, if supplied, over Properties before
+	// applying either.
+	if req.PropertyOverlayURL != "" {
+		overlay, ferr := s.fetchRemotePropertyOverlay(req.PropertyOverlayURL, req.RequestId)
+		if ferr != nil {
+			slog.Error("processProperty fetch remote property overlay failed", "err", ferr, "propertyOverlayUrl", req.PropertyOverlayURL, "requestId", req.RequestId, logTag)
+			err = fmt.Errorf("fetch remote property overlay: %w", ferr)
+			return
+		}
+		if req.Properties == nil {
+			req.Properties = make(map[string]map[string]interface{})
+		}
+		for extensionName, props := range overlay {
+			if existing, ok := req.Properties[extensionName]; ok {
+				req.Properties[extensionName] = mergeProperties(existing, props)
+			} else {
+				req.Properties[extensionName] = props
+			}
+		}
+	}
+
 	// Set additional properties to property.json
 	for extensionName, props := range req.Properties {
 		if extensionName != "" {
@@ -665,12 +1040,12 @@ func (s *HttpServer) processProperty(req *StartReq, tenappDir string) (propertyJ
 		}
 	}
 
-	// Validate environment variables in the "nodes" section
-	// Support optional env placeholder with default: ${env:VAR|default}
-	// Capture groups:
-	//  1) variable name
-	//  2) optional default part starting with '|', may be empty string like '|'
-	envPattern := regexp.MustCompile(`\${env:([^}|]+)(\|[^}]*)?}`)
+	// Resolve secret references in the "nodes" section, e.g.
+	// ${env:VAR|default}, ${file:/path/to/secret}, or any scheme
+	// registered via WithSecretResolver. Unlike the old env-only check,
+	// resolved values are substituted directly into property.json rather
+	// than left for the worker process to expand.
+	var secretFailures []secretResolutionFailure
 	for _, graph := range newGraphs {
 		graphMap, _ := graph.(map[string]interface{})
 		graphData, _ := graphMap["graph"].(map[string]interface{})
@@ -681,9 +1056,9 @@ func (s *HttpServer) processProperty(req *StartReq, tenappDir string) (propertyJ
 		}
 		for _, node := range nodes {
 			nodeMap, _ := node.(map[string]interface{})
+			extensionName, _ := nodeMap["name"].(string)
 			properties, ok := nodeMap["property"].(map[string]interface{})
 			if !ok {
-				// slog.Info("No property section in the node", "node", nodeMap, "requestId", req.RequestId, logTag)
 				continue
 			}
 			for key, val := range properties {
@@ -691,36 +1066,31 @@ func (s *HttpServer) processProperty(req *StartReq, tenappDir string) (propertyJ
 				if !ok {
 					continue
 				}
-				// Log the property value being processed
-				// slog.Info("Processing property", "key", key, "value", strVal)
-
-				matches := envPattern.FindAllStringSubmatch(strVal, -1)
-				// if len(matches) == 0 {
-				// 	slog.Info("No environment variable patterns found in property", "key", key, "value", strVal)
-				// }
 
-				for _, match := range matches {
-					if len(match) < 2 {
-						continue
-					}
-					variable := match[1]
-					// match[2] contains the optional default part (e.g., "|some-default" or just "|")
-					hasDefault := len(match) >= 3 && match[2] != ""
-					exists := os.Getenv(variable) != ""
-					// slog.Info("Checking environment variable", "variable", variable, "exists", exists, "hasDefault", hasDefault)
-					if !exists {
-						if hasDefault {
-							// Optional env not set; skip error logging
-							slog.Info("Optional environment variable not set; using default", "variable", variable, "property", key, "requestId", req.RequestId, logTag)
-						} else {
-							slog.Error("Environment variable not found", "variable", variable, "property", key, "requestId", req.RequestId, logTag)
-						}
-					}
+				resolved, applied, failures := s.secrets.resolveAllDetailed(strVal)
+				if len(failures) > 0 {
+					slog.Error("Secret reference could not be resolved", "property", key, "requestId", req.RequestId, logTag)
+					secretFailures = append(secretFailures, failures...)
+					continue
+				}
+				properties[key] = resolved
+				for _, a := range applied {
+					secretSources = append(secretSources, resolvedValueSource{
+						ExtensionName: extensionName,
+						Property:      key,
+						SecretRef:     a.Ref,
+						Value:         a.Value,
+						ResolvedAt:    time.Now(),
+					})
 				}
 			}
-
 		}
 	}
+	if len(secretFailures) > 0 {
+		err = &SecretResolutionError{Failures: secretFailures}
+		return
+	}
+	resolvedPropertyJson = propertyJson
 
 	// Marshal the modified JSON back to a string
 	modifiedPropertyJson, err := json.MarshalIndent(propertyJson, "", "  ")
@@ -731,89 +1101,34 @@ func (s *HttpServer) processProperty(req *StartReq, tenappDir string) (propertyJ
 
 	ts := time.Now().Format("20060102_150405_000")
 
-	// Use a more reliable temp directory if LogPath is not writable
-	tempDir := s.config.LogPath
-
-	// Test if we can actually write to the directory by trying to create a test file
-	testFile := filepath.Join(tempDir, "test-write-permission")
-	if testFileHandle, testErr := os.Create(testFile); testErr != nil {
-		// Fallback to system temp directory
-		tempDir = os.TempDir()
-		slog.Info("Using system temp directory as fallback", "requestId", req.RequestId, "tempDir", tempDir, "originalLogPath", s.config.LogPath, "testErr", testErr, logTag)
-	} else {
-		// Clean up test file
-		testFileHandle.Close()
-		os.Remove(testFile)
-		slog.Info("LogPath is writable", "requestId", req.RequestId, "tempDir", tempDir, logTag)
-	}
-
 	// Validate and sanitize channel name to prevent path injection
 	safeChannelName, err := sanitizeChannelName(req.ChannelName)
 	if err != nil {
 		slog.Error("Invalid channel name", "channelName", req.ChannelName, "requestId", req.RequestId, "err", err, logTag)
-		return "", "", fmt.Errorf("invalid channel name: %w", err)
+		return "", "", "", nil, nil, nil, fmt.Errorf("invalid channel name: %w", err)
 	}
 
-	propertyJsonFile = filepath.Join(tempDir, fmt.Sprintf("property-%s-%s.json", safeChannelName, ts))
-	// Ensure absolute path for property.json file
-	propertyJsonFile, err = filepath.Abs(propertyJsonFile)
-	if err != nil {
-		slog.Error("Failed to get absolute path for property.json", "err", err, "requestId", req.RequestId, logTag)
-		return "", "", err
-	}
+	propertyJsonName = fmt.Sprintf("property-%s-%s.json", safeChannelName, ts)
+	logName := fmt.Sprintf("app-%s-%s.log", safeChannelName, ts)
 
-	// Validate that the final path is within the expected directory
-	if !isPathSafe(propertyJsonFile, tempDir) {
-		slog.Error("Path traversal detected", "propertyJsonFile", propertyJsonFile, "tempDir", tempDir, "requestId", req.RequestId, logTag)
-		return "", "", fmt.Errorf("path traversal detected in property file path")
-	}
-	logFile = fmt.Sprintf("%s/app-%s-%s.log", s.config.LogPath, safeChannelName, ts)
+	slog.Info("Writing property.json via property store", "requestId", req.RequestId, "name", propertyJsonName, logTag)
 
-	// Debug logging
-	slog.Info("Writing temporary property.json file", "requestId", req.RequestId, "propertyJsonFile", propertyJsonFile, "logPath", s.config.LogPath, logTag)
-
-	// Ensure the directory exists before writing the file
-	dir := filepath.Dir(propertyJsonFile)
-	slog.Info("Creating directory", "requestId", req.RequestId, "dir", dir, logTag)
-	if mkdirErr := os.MkdirAll(dir, 0755); mkdirErr != nil {
-		slog.Error("Failed to create directory for property.json file", "err", mkdirErr, "dir", dir, "requestId", req.RequestId, logTag)
-		return
-	}
-	slog.Info("Directory created successfully", "requestId", req.RequestId, "dir", dir, logTag)
-
-	// Check if directory exists and is writable
-	if stat, statErr := os.Stat(dir); statErr != nil {
-		slog.Error("Directory stat failed", "err", statErr, "dir", dir, "requestId", req.RequestId, logTag)
-		return
-	} else {
-		slog.Info("Directory stat", "requestId", req.RequestId, "dir", dir, "mode", stat.Mode(), "isDir", stat.IsDir(), logTag)
+	propertyFile, err := s.store.Create(propertyJsonName)
+	if err != nil {
+		slog.Error("Failed to create property.json via property store", "err", err, "name", propertyJsonName, "requestId", req.RequestId, logTag)
+		return "", "", "", nil, nil, nil, err
 	}
+	defer propertyFile.Close()
 
-	// Additional debugging for file path
-	slog.Info("About to write file", "requestId", req.RequestId, "propertyJsonFile", propertyJsonFile, "fileSize", len(modifiedPropertyJson), logTag)
-
-	// Try to create the file first to see if there are any permission issues
-	file, createErr := os.Create(propertyJsonFile)
-	if createErr != nil {
-		slog.Error("Failed to create file", "err", createErr, "propertyJsonFile", propertyJsonFile, "requestId", req.RequestId, logTag)
-		return
+	if _, err = propertyFile.Write(modifiedPropertyJson); err != nil {
+		slog.Error("Failed to write property.json via property store", "err", err, "name", propertyJsonName, "requestId", req.RequestId, logTag)
+		return "", "", "", nil, nil, nil, err
 	}
-	defer file.Close()
 
-	// Write content to file
-	_, writeErr := file.Write([]byte(modifiedPropertyJson))
-	if writeErr != nil {
-		slog.Error("Failed to write content to file", "err", writeErr, "propertyJsonFile", propertyJsonFile, "requestId", req.RequestId, logTag)
-		return
-	}
+	propertyJsonFile = s.store.URI(propertyJsonName)
+	logFile = s.store.URI(logName)
 
-	// Sync to ensure data is written to disk
-	if syncErr := file.Sync(); syncErr != nil {
-		slog.Error("Failed to sync file", "err", syncErr, "propertyJsonFile", propertyJsonFile, "requestId", req.RequestId, logTag)
-		return
-	}
-
-	slog.Info("Successfully wrote temporary property.json file", "requestId", req.RequestId, "propertyJsonFile", propertyJsonFile, logTag)
+	slog.Info("Successfully wrote property.json", "requestId", req.RequestId, "propertyJsonFile", propertyJsonFile, "logFile", logFile, logTag)
 
 	return
 }
@@ -822,25 +1137,61 @@ func (s *HttpServer) Start() {
 	r := gin.Default()
 	r.Use(corsMiddleware())
 
+	auth := s.requireHMACAuth()
+
 	r.GET("/", s.handlerHealth)
 	r.GET("/health", s.handlerHealth)
+	r.GET("/healthz", s.handlerHealth)
+	r.GET("/readyz", s.handlerReadyz)
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	r.GET("/list", s.handlerList)
-	r.POST("/start", s.handlerStart)
-	r.POST("/stop", s.handlerStop)
+	r.POST("/start", auth, s.handlerStart)
+	r.POST("/stop", auth, s.handlerStop)
 	r.POST("/ping", s.handlerPing)
 	r.GET("/graphs", s.handleGraphs)
+	r.GET("/graphs/live", s.handleGraphsLive)
+	r.GET("/graphs/:id", s.handleGraphLiveState)
+	r.GET("/graphs/:id/events", s.handleGraphLiveEvents)
 	r.GET("/dev-tmp/addons/default-properties", s.handleAddonDefaultProperties)
-	r.POST("/token/generate", s.handlerGenerateToken)
+	r.POST("/token/generate", auth, s.handlerGenerateToken)
 	r.GET("/vector/document/preset/list", s.handlerVectorDocumentPresetList)
-	r.POST("/vector/document/update", s.handlerVectorDocumentUpdate)
-	r.POST("/vector/document/upload", s.handlerVectorDocumentUpload)
+	r.POST("/vector/document/update", auth, s.handlerVectorDocumentUpdate)
+	r.POST("/vector/document/upload", auth, s.handlerVectorDocumentUpload)
+	r.POST("/vector_document/uploads", auth, s.handlerVectorDocumentUploadInit)
+	r.PATCH("/vector_document/uploads/:id", auth, s.handlerVectorDocumentUploadChunk)
+	r.HEAD("/vector_document/uploads/:id", s.handlerVectorDocumentUploadHead)
+	r.PUT("/vector_document/uploads/:id", auth, s.handlerVectorDocumentUploadFinalize)
+	r.DELETE("/channels/:name/pending", auth, s.handlerChannelCancelPending)
+	r.GET("/channels/:name/properties", auth, s.handlerChannelProperties)
+	r.GET("/property/resolve", auth, s.handlerPropertyResolve)
+	r.POST("/channels/:name/reload", auth, s.handlerChannelReload)
+	r.GET("/catalog/graphs", s.handlerCatalogGraphsList)
+	r.POST("/catalog/graphs/:name/install", auth, s.handlerCatalogGraphInstall)
+	r.DELETE("/catalog/graphs/:name", auth, s.handlerCatalogGraphUninstall)
+	r.GET("/operations", s.handlerOperationsList)
+	r.GET("/operations/:id", s.handlerOperationGet)
+	r.DELETE("/operations/:id", auth, s.handlerOperationCancel)
+	r.GET("/operations/:id/events", s.handlerOperationEvents)
 
 	slog.Info("server start", "port", s.config.Port, logTag)
 
 	go timeoutWorkers()
+	startLiveStatePoller(s.liveState, []LiveStateReporter{LogReporter{}})
+	go s.pollWorkerPoolMetrics()
 	r.Run(fmt.Sprintf(":%s", s.config.Port))
 }
 
+// pollWorkerPoolMetrics keeps the workers_active/workers_draining gauges
+// fresh for the /metrics endpoint.
+func (s *HttpServer) pollWorkerPoolMetrics() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.workerPool.UpdateMetrics()
+	}
+}
+
 // sanitizeChannelName validates and sanitizes channel name to prevent path injection
 func sanitizeChannelName(channelName string) (string, error) {
 	if channelName == "" {
@@ -892,19 +1243,3 @@ func sanitizeChannelName(channelName string) (string, error) {
 
 	return sanitized, nil
 }
-
-// isPathSafe validates that the given path is within the expected base directory
-func isPathSafe(path, baseDir string) bool {
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return false
-	}
-
-	absBase, err := filepath.Abs(baseDir)
-	if err != nil {
-		return false
-	}
-
-	// Check if the path is within the base directory
-	return strings.HasPrefix(absPath, absBase)
-}
@@ -0,0 +1,235 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRecentEvents bounds how many recent cmd/data timestamps are kept per
+// extension so the live-state store doesn't grow unbounded for long-lived
+// graphs.
+const maxRecentEvents = 20
+
+// ExtensionState is the last known lifecycle state of a single node inside
+// a graph, as observed by the live-state reporter.
+type ExtensionState struct {
+	Name         string    `json:"name"`
+	Addon        string    `json:"addon"`
+	Status       string    `json:"status"` // e.g. "starting", "started", "stopped"
+	LastEventTs  []int64   `json:"last_event_ts"`
+	ErrorCount   int       `json:"error_count"`
+	LastErrorTs  time.Time `json:"last_error_ts,omitempty"`
+	LastErrorMsg string    `json:"last_error_msg,omitempty"`
+}
+
+// GraphSnapshot is a point-in-time view of one running graph, keyed by the
+// graph ID the runtime assigned it (the root graph ID is the channel name
+// for graphs started via /start; subgraphs started with NewStartGraphCmd
+// get their own graph_id).
+type GraphSnapshot struct {
+	GraphID    string                     `json:"graph_id"`
+	ChannelName string                    `json:"channel_name"`
+	CreateTs   int64                      `json:"create_ts"`
+	Extensions map[string]*ExtensionState `json:"extensions"`
+}
+
+// GraphEvent is pushed to subscribers of a graph's /events stream.
+type GraphEvent struct {
+	GraphID       string    `json:"graph_id"`
+	ExtensionName string    `json:"extension_name,omitempty"`
+	Kind          string    `json:"kind"` // "cmd", "data", "state", "error"
+	Detail        string    `json:"detail,omitempty"`
+	Ts            time.Time `json:"ts"`
+}
+
+// LiveStateStore holds an in-memory view of every graph currently known to
+// the reporter, and fans out events to interested SSE subscribers. All
+// methods are safe for concurrent use.
+type LiveStateStore struct {
+	mu     sync.RWMutex
+	graphs map[string]*GraphSnapshot
+
+	subMu       sync.Mutex
+	subscribers map[string][]chan GraphEvent
+}
+
+// NewLiveStateStore creates an empty store.
+func NewLiveStateStore() *LiveStateStore {
+	return &LiveStateStore{
+		graphs:      make(map[string]*GraphSnapshot),
+		subscribers: make(map[string][]chan GraphEvent),
+	}
+}
+
+// Upsert creates or refreshes the snapshot for graphID.
+func (s *LiveStateStore) Upsert(graphID, channelName string, createTs int64) *GraphSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.graphs[graphID]
+	if !ok {
+		g = &GraphSnapshot{
+			GraphID:     graphID,
+			ChannelName: channelName,
+			CreateTs:    createTs,
+			Extensions:  make(map[string]*ExtensionState),
+		}
+		s.graphs[graphID] = g
+	}
+	return g
+}
+
+// Remove drops a graph from the store, e.g. once its worker has stopped.
+func (s *LiveStateStore) Remove(graphID string) {
+	s.mu.Lock()
+	delete(s.graphs, graphID)
+	s.mu.Unlock()
+}
+
+// Snapshot returns a copy of every known graph.
+func (s *LiveStateStore) Snapshot() []*GraphSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]*GraphSnapshot, 0, len(s.graphs))
+	for _, g := range s.graphs {
+		cp := *g
+		cp.Extensions = make(map[string]*ExtensionState, len(g.Extensions))
+		for name, ext := range g.Extensions {
+			extCopy := *ext
+			extCopy.LastEventTs = append([]int64(nil), ext.LastEventTs...)
+			cp.Extensions[name] = &extCopy
+		}
+		out = append(out, &cp)
+	}
+	return out
+}
+
+// Graph returns the snapshot for a single graph ID, if known.
+func (s *LiveStateStore) Graph(graphID string) (*GraphSnapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g, ok := s.graphs[graphID]
+	return g, ok
+}
+
+// RecordEvent updates the relevant extension's state and fans the event out
+// to any subscribers of graphID.
+func (s *LiveStateStore) RecordEvent(ev GraphEvent) {
+	s.mu.Lock()
+	g, ok := s.graphs[ev.GraphID]
+	if ok {
+		ext, ok := g.Extensions[ev.ExtensionName]
+		if !ok {
+			ext = &ExtensionState{Name: ev.ExtensionName}
+			g.Extensions[ev.ExtensionName] = ext
+		}
+		switch ev.Kind {
+		case "error":
+			ext.ErrorCount++
+			ext.LastErrorTs = ev.Ts
+			ext.LastErrorMsg = ev.Detail
+		case "state":
+			ext.Status = ev.Detail
+		default:
+			ext.LastEventTs = append(ext.LastEventTs, ev.Ts.Unix())
+			if len(ext.LastEventTs) > maxRecentEvents {
+				ext.LastEventTs = ext.LastEventTs[len(ext.LastEventTs)-maxRecentEvents:]
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	s.publish(ev)
+}
+
+// extensionNodeNames returns the name (and addon, when present) of every
+// node in propertyJson's (already graph-filtered, see setNodeProperty)
+// predefined_graphs section, so the live-state store can seed one
+// ExtensionState per node at start time instead of reporting an empty
+// Extensions map.
+func extensionNodeNames(propertyJson map[string]interface{}) []ExtensionState {
+	tenSection, ok := propertyJson["ten"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	graphs, ok := tenSection["predefined_graphs"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var states []ExtensionState
+	for _, graph := range graphs {
+		graphMap, ok := graph.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		graphData, ok := graphMap["graph"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		nodes, ok := graphData["nodes"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, node := range nodes {
+			nodeMap, ok := node.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := nodeMap["name"].(string)
+			if name == "" {
+				continue
+			}
+			addon, _ := nodeMap["addon"].(string)
+			states = append(states, ExtensionState{Name: name, Addon: addon})
+		}
+	}
+	return states
+}
+
+// Subscribe registers a channel that receives every future event for
+// graphID. The returned func unsubscribes and closes the channel.
+func (s *LiveStateStore) Subscribe(graphID string) (<-chan GraphEvent, func()) {
+	ch := make(chan GraphEvent, 32)
+
+	s.subMu.Lock()
+	s.subscribers[graphID] = append(s.subscribers[graphID], ch)
+	s.subMu.Unlock()
+
+	unsubscribe := func() {
+		s.subMu.Lock()
+		defer s.subMu.Unlock()
+		subs := s.subscribers[graphID]
+		for i, c := range subs {
+			if c == ch {
+				s.subscribers[graphID] = append(subs[:i], subs[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+func (s *LiveStateStore) publish(ev GraphEvent) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	for _, ch := range s.subscribers[ev.GraphID] {
+		select {
+		case ch <- ev:
+		default:
+			// Slow subscriber; drop the event rather than block the reporter.
+		}
+	}
+}
@@ -0,0 +1,124 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"log/slog"
+	"time"
+)
+
+// liveStatePollInterval is how often the background poller refreshes graph
+// snapshots from the running workers.
+const liveStatePollInterval = 2 * time.Second
+
+// LiveStateReporter lets users push periodic snapshots to an external
+// system (Prometheus, a control plane, ...) instead of only reading them
+// back through the HTTP endpoints. Implementations must be safe to call
+// from the polling goroutine.
+type LiveStateReporter interface {
+	Report(snapshots []*GraphSnapshot)
+}
+
+// LogReporter is the default LiveStateReporter: it just logs a summary line
+// per poll, which is useful during development and as a reference
+// implementation for custom reporters.
+type LogReporter struct{}
+
+func (LogReporter) Report(snapshots []*GraphSnapshot) {
+	slog.Debug("live state poll", "graphs", len(snapshots), logTag)
+}
+
+// startLiveStatePoller launches a background goroutine that keeps store in
+// sync with the channels currently tracked in workers, and fans snapshots
+// out to reporters. It returns immediately; the goroutine runs until the
+// process exits.
+func startLiveStatePoller(store *LiveStateStore, reporters []LiveStateReporter) {
+	go func() {
+		ticker := time.NewTicker(liveStatePollInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			pollLiveState(store)
+
+			snapshots := store.Snapshot()
+			for _, r := range reporters {
+				r.Report(snapshots)
+			}
+		}
+	}()
+}
+
+// recordGraphStarted seeds one ExtensionState per node in the channel's
+// resolved property.json and marks each "started", so GET /graphs/:id
+// reflects real per-extension state from the moment the worker comes up
+// instead of waiting for the next poll to merely mirror the workers
+// registry into an empty Extensions map.
+func (s *HttpServer) recordGraphStarted(channelName string, createTs int64, resolvedPropertyJson map[string]interface{}) {
+	s.liveState.Upsert(channelName, channelName, createTs)
+
+	now := time.Now()
+	for _, ext := range extensionNodeNames(resolvedPropertyJson) {
+		s.liveState.RecordEvent(GraphEvent{
+			GraphID:       channelName,
+			ExtensionName: ext.Name,
+			Kind:          "state",
+			Detail:        "started",
+			Ts:            now,
+		})
+	}
+}
+
+// recordGraphStopped marks every known extension in channelName's graph
+// "stopped" before the worker (and its live-state entry) is torn down, so
+// an in-flight /graphs/:id/events subscriber observes the transition.
+func (s *HttpServer) recordGraphStopped(channelName string) {
+	g, ok := s.liveState.Graph(channelName)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	for name := range g.Extensions {
+		s.liveState.RecordEvent(GraphEvent{
+			GraphID:       channelName,
+			ExtensionName: name,
+			Kind:          "state",
+			Detail:        "stopped",
+			Ts:            now,
+		})
+	}
+}
+
+// pollLiveState reconciles the store with the currently running workers.
+// Each worker's channel is treated as its root graph; subgraphs started
+// dynamically via NewStartGraphCmd register themselves through
+// LiveStateStore.Upsert/RecordEvent as the runtime observes them.
+func pollLiveState(store *LiveStateStore) {
+	known := make(map[string]struct{})
+
+	for _, channelName := range workers.Keys() {
+		w, ok := workers.Get(channelName).(*Worker)
+		if !ok {
+			continue
+		}
+
+		known[channelName] = struct{}{}
+		store.Upsert(channelName, w.ChannelName, w.CreateTs)
+	}
+
+	for _, g := range store.Snapshot() {
+		if g.ChannelName == "" {
+			// Not a worker-rooted graph (e.g. a dynamically started
+			// subgraph); leave it alone, it is removed explicitly.
+			continue
+		}
+		if _, ok := known[g.ChannelName]; !ok {
+			store.Remove(g.GraphID)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OperationStatus is the lifecycle state of an async Operation.
+type OperationStatus string
+
+const (
+	OperationPending   OperationStatus = "pending"
+	OperationRunning   OperationStatus = "running"
+	OperationSuccess   OperationStatus = "success"
+	OperationFailure   OperationStatus = "failure"
+	OperationCancelled OperationStatus = "cancelled"
+)
+
+// operationTTL is how long a finished operation stays in the registry
+// before being garbage collected, giving slow pollers a window to catch
+// the final status.
+const operationTTL = 10 * time.Minute
+
+// OperationEvent is a progress update published while an operation runs,
+// delivered to GET /operations/{id}/events subscribers.
+type OperationEvent struct {
+	Phase          string    `json:"phase"`
+	BytesProcessed int64     `json:"bytes_processed,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Ts             time.Time `json:"ts"`
+}
+
+// Operation tracks one long-running action (worker start/stop, vector
+// document upload/update) so callers can poll or stream its progress
+// instead of blocking on the HTTP request until it completes.
+type Operation struct {
+	ID          string          `json:"operation_id"`
+	Kind        string          `json:"kind"`
+	Status      OperationStatus `json:"status"`
+	ResourceURL string          `json:"resource_url"`
+	ChannelName string          `json:"channel_name,omitempty"`
+	RequestId   string          `json:"request_id,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	FinishedAt  time.Time       `json:"finished_at,omitempty"`
+	Error       string          `json:"error,omitempty"`
+
+	mu          sync.Mutex
+	subscribers []chan OperationEvent
+	cancel      func()
+}
+
+// operationRegistry is a minimal concurrent map, mirroring the shape of
+// the existing `workers` registry, keyed by operation ID.
+type operationRegistry struct {
+	mu    sync.RWMutex
+	items map[string]*Operation
+}
+
+func newOperationRegistry() *operationRegistry {
+	return &operationRegistry{items: make(map[string]*Operation)}
+}
+
+func (r *operationRegistry) Set(id string, op *Operation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items[id] = op
+}
+
+func (r *operationRegistry) Get(id string) (*Operation, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	op, ok := r.items[id]
+	return op, ok
+}
+
+func (r *operationRegistry) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.items, id)
+}
+
+func (r *operationRegistry) List() []*Operation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]*Operation, 0, len(r.items))
+	for _, op := range r.items {
+		out = append(out, op)
+	}
+	return out
+}
+
+// operations is the registry of in-flight and recently finished
+// operations, analogous to the existing `workers` registry.
+var operations = newOperationRegistry()
+
+// newOperation creates and registers an Operation of the given kind,
+// wiring cancel as the function DELETE /operations/{id} should invoke.
+func newOperation(kind, channelName, requestId string, cancel func()) *Operation {
+	op := &Operation{
+		ID:          uuid.NewString(),
+		Kind:        kind,
+		Status:      OperationPending,
+		ChannelName: channelName,
+		RequestId:   requestId,
+		CreatedAt:   time.Now(),
+		cancel:      cancel,
+	}
+	op.ResourceURL = fmt.Sprintf("/operations/%s", op.ID)
+
+	operations.Set(op.ID, op)
+	return op
+}
+
+// setRunning marks the operation as running and publishes a "started"
+// progress event.
+func (op *Operation) setRunning() {
+	op.mu.Lock()
+	op.Status = OperationRunning
+	op.mu.Unlock()
+
+	op.Publish(OperationEvent{Phase: "started", Ts: time.Now()})
+}
+
+// Publish fans a progress event out to every subscriber of this
+// operation's /events stream. Slow subscribers have events dropped rather
+// than blocking the worker doing the publishing.
+func (op *Operation) Publish(ev OperationEvent) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	for _, ch := range op.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a channel that receives every future progress event
+// for this operation. The returned func unsubscribes and closes the
+// channel.
+func (op *Operation) Subscribe() (<-chan OperationEvent, func()) {
+	ch := make(chan OperationEvent, 32)
+
+	op.mu.Lock()
+	op.subscribers = append(op.subscribers, ch)
+	op.mu.Unlock()
+
+	unsubscribe := func() {
+		op.mu.Lock()
+		defer op.mu.Unlock()
+		for i, c := range op.subscribers {
+			if c == ch {
+				op.subscribers = append(op.subscribers[:i], op.subscribers[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// finish marks the operation as finished with status, records err if any,
+// and schedules it for GC after operationTTL.
+func (op *Operation) finish(status OperationStatus, err error) {
+	op.mu.Lock()
+	op.Status = status
+	op.FinishedAt = time.Now()
+	if err != nil {
+		op.Error = err.Error()
+	}
+	op.mu.Unlock()
+
+	ev := OperationEvent{Phase: string(status), Ts: time.Now()}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	op.Publish(ev)
+
+	time.AfterFunc(operationTTL, func() {
+		operations.Remove(op.ID)
+	})
+}
+
+// Cancel requests cancellation of the underlying work, if the operation
+// supports it, and marks the operation cancelled.
+func (op *Operation) Cancel() bool {
+	op.mu.Lock()
+	cancel := op.cancel
+	op.mu.Unlock()
+
+	if cancel == nil {
+		return false
+	}
+
+	cancel()
+	op.finish(OperationCancelled, nil)
+	return true
+}
+
+// snapshotOperation copies the JSON-visible fields of op without its
+// mutex/subscribers, since Operation itself isn't safe to marshal
+// concurrently with Publish/finish.
+func snapshotOperation(op *Operation) *Operation {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	return &Operation{
+		ID:          op.ID,
+		Kind:        op.Kind,
+		Status:      op.Status,
+		ResourceURL: op.ResourceURL,
+		ChannelName: op.ChannelName,
+		RequestId:   op.RequestId,
+		CreatedAt:   op.CreatedAt,
+		FinishedAt:  op.FinishedAt,
+		Error:       op.Error,
+	}
+}
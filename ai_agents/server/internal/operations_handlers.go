@@ -0,0 +1,137 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handlerOperationsList lists every in-flight or recently finished
+// operation.
+func (s *HttpServer) handlerOperationsList(c *gin.Context) {
+	list := operations.List()
+	out := make([]*Operation, 0, len(list))
+	for _, op := range list {
+		out = append(out, snapshotOperation(op))
+	}
+	s.output(c, codeSuccess, out)
+}
+
+// handlerOperationGet polls a single operation. A `?wait=30s` query param
+// makes this a long-poll: the handler blocks (up to the given duration,
+// capped at 60s) until the operation leaves pending/running, or returns
+// immediately on the existing status.
+func (s *HttpServer) handlerOperationGet(c *gin.Context) {
+	op, ok := operations.Get(c.Param("id"))
+	if !ok {
+		s.output(c, codeErrChannelNotExisted, http.StatusNotFound)
+		return
+	}
+
+	if waitParam := c.Query("wait"); waitParam != "" {
+		wait, err := time.ParseDuration(waitParam)
+		if err != nil {
+			s.output(c, codeErrParamsInvalid, http.StatusBadRequest)
+			return
+		}
+		if wait > 60*time.Second {
+			wait = 60 * time.Second
+		}
+		waitForOperationSettled(c, op, wait)
+	}
+
+	s.output(c, codeSuccess, snapshotOperation(op))
+}
+
+// waitForOperationSettled blocks until op leaves pending/running, the
+// client disconnects, or the wait budget expires.
+func waitForOperationSettled(c *gin.Context, op *Operation, wait time.Duration) {
+	deadline := time.After(wait)
+	events, unsubscribe := op.Subscribe()
+	defer unsubscribe()
+
+	for {
+		op.mu.Lock()
+		settled := op.Status != OperationPending && op.Status != OperationRunning
+		op.mu.Unlock()
+		if settled {
+			return
+		}
+
+		select {
+		case <-events:
+			continue
+		case <-deadline:
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// handlerOperationCancel cancels an in-flight operation.
+func (s *HttpServer) handlerOperationCancel(c *gin.Context) {
+	op, ok := operations.Get(c.Param("id"))
+	if !ok {
+		s.output(c, codeErrChannelNotExisted, http.StatusNotFound)
+		return
+	}
+
+	if !op.Cancel() {
+		s.output(c, codeErrParamsInvalid, http.StatusConflict)
+		return
+	}
+
+	s.output(c, codeSuccess, snapshotOperation(op))
+}
+
+// handlerOperationEvents streams an operation's progress events as
+// Server-Sent Events.
+func (s *HttpServer) handlerOperationEvents(c *gin.Context) {
+	op, ok := operations.Get(c.Param("id"))
+	if !ok {
+		s.output(c, codeErrChannelNotExisted, http.StatusNotFound)
+		return
+	}
+
+	events, unsubscribe := op.Subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return false
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Phase, mustJSON(ev))
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func mustJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("failed to marshal SSE event", "err", err, logTag)
+		return "{}"
+	}
+	return string(b)
+}
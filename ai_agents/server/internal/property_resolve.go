@@ -0,0 +1,115 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// resolvePropertyJson finds the base property.json by walking upward from
+// workingDir towards baseDir (never above it) and layers
+// property.<env>.json, property.<channelName>.json, and
+// property.local.json on top, in that order, when present in the same
+// directory as the base file. Each layer is deep-merged over the
+// accumulated result via mergeProperties. The returned layers slice lists
+// every file actually applied, base file first, for the caller to surface
+// for debugging.
+func (s *HttpServer) resolvePropertyJson(baseDir, workingDir, channelName string) (map[string]interface{}, []string, error) {
+	baseFile, layerDir, err := findPropertyJsonUpward(workingDir, baseDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged, err := readPropertyJsonFile(baseFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	layers := []string{baseFile}
+
+	for _, overlayName := range s.propertyOverlayNames(channelName) {
+		overlayPath := filepath.Join(layerDir, overlayName)
+		if _, statErr := os.Stat(overlayPath); statErr != nil {
+			continue
+		}
+
+		overlay, err := readPropertyJsonFile(overlayPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		merged = mergeProperties(merged, overlay)
+		layers = append(layers, overlayPath)
+	}
+
+	return merged, layers, nil
+}
+
+// propertyOverlayNames lists the overlay filenames to look for, in
+// precedence order (later entries win), after the channel name has been
+// sanitized the same way processProperty sanitizes it for file naming.
+func (s *HttpServer) propertyOverlayNames(channelName string) []string {
+	var names []string
+	if s.config.Env != "" {
+		names = append(names, fmt.Sprintf("property.%s.json", s.config.Env))
+	}
+	if safeChannelName, err := sanitizeChannelName(channelName); err == nil && safeChannelName != "" {
+		names = append(names, fmt.Sprintf("property.%s.json", safeChannelName))
+	}
+	names = append(names, "property.local.json")
+	return names
+}
+
+// findPropertyJsonUpward looks for property.json in startDir, then each
+// parent directory in turn, stopping at (and never searching above)
+// baseDir. startDir must itself be inside baseDir.
+func findPropertyJsonUpward(startDir, baseDir string) (propertyJsonPath string, dir string, err error) {
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return "", "", fmt.Errorf("property resolve: base dir %q: %w", baseDir, err)
+	}
+
+	absDir, err := filepath.Abs(startDir)
+	if err != nil {
+		return "", "", fmt.Errorf("property resolve: working dir %q: %w", startDir, err)
+	}
+	if !isPathSafe(absDir, absBase) {
+		return "", "", fmt.Errorf("property resolve: working dir %q is outside property base dir %q", startDir, baseDir)
+	}
+
+	for {
+		candidate := filepath.Join(absDir, "property.json")
+		if _, statErr := os.Stat(candidate); statErr == nil {
+			return candidate, absDir, nil
+		}
+
+		if absDir == absBase {
+			return "", "", fmt.Errorf("property resolve: no property.json found between %q and %q", startDir, baseDir)
+		}
+
+		parent := filepath.Dir(absDir)
+		if parent == absDir || !isPathSafe(parent, absBase) {
+			return "", "", fmt.Errorf("property resolve: no property.json found between %q and %q", startDir, baseDir)
+		}
+		absDir = parent
+	}
+}
+
+func readPropertyJsonFile(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("property resolve: read %q: %w", path, err)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("property resolve: parse %q: %w", path, err)
+	}
+	return m, nil
+}
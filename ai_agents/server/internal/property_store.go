@@ -0,0 +1,291 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PropertyStore is the persistence backend processProperty writes
+// property.json through, modeled on syncthing's fs.Filesystem: callers
+// work with plain names, and it's the implementation's job to decide
+// where (and how safely) those names actually land.
+type PropertyStore interface {
+	// Create opens name for writing, creating any parent directories the
+	// backend needs and truncating existing content at that name.
+	Create(name string) (io.WriteCloser, error)
+	// Stat reports name's metadata, or an error satisfying os.IsNotExist
+	// if name doesn't exist.
+	Stat(name string) (fs.FileInfo, error)
+	// URI returns the URI downstream workers should use to read name
+	// back, e.g. "file:///var/log/ten/app-foo.log" or "s3://bucket/foo".
+	URI(name string) string
+	// Remove deletes name. Removing a name that doesn't exist is not an
+	// error.
+	Remove(name string) error
+}
+
+// LogSink is a PropertyStore used for log output rather than
+// property.json; the two share the same small surface, so one interface
+// backs both roles.
+type LogSink = PropertyStore
+
+// isPathSafe reports whether path resolves to somewhere inside (or equal
+// to) baseDir, guarding against a sanitized-but-still-malicious name
+// escaping via "..". A plain strings.HasPrefix(absPath, absBase) is not
+// enough here: baseDir "/srv/catalog" would wrongly accept
+// "/srv/catalog-admin", a sibling directory that merely shares the
+// prefix -- the comparison must require a path separator (or exact
+// equality) at the boundary.
+func isPathSafe(path, baseDir string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return false
+	}
+
+	return absPath == absBase || strings.HasPrefix(absPath, absBase+string(filepath.Separator))
+}
+
+// LocalPropertyStore writes to a local directory, falling back to the
+// system temp directory if BaseDir is not writable when the store is
+// first used (the "test if writable, fallback to TempDir" dance that used
+// to live directly inside processProperty). Writability is tested only
+// once; the chosen directory is kept for the store's lifetime.
+type LocalPropertyStore struct {
+	BaseDir string
+
+	resolveOnce sync.Once
+	resolvedDir string
+}
+
+// NewLocalPropertyStore returns a LocalPropertyStore rooted at baseDir.
+func NewLocalPropertyStore(baseDir string) *LocalPropertyStore {
+	return &LocalPropertyStore{BaseDir: baseDir}
+}
+
+func (l *LocalPropertyStore) dir() string {
+	l.resolveOnce.Do(func() {
+		l.resolvedDir = l.BaseDir
+
+		testFile := filepath.Join(l.resolvedDir, "test-write-permission")
+		if f, err := os.Create(testFile); err != nil {
+			l.resolvedDir = os.TempDir()
+		} else {
+			f.Close()
+			os.Remove(testFile)
+		}
+	})
+	return l.resolvedDir
+}
+
+func (l *LocalPropertyStore) resolve(name string) (string, error) {
+	dir := l.dir()
+	absPath, err := filepath.Abs(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	if !isPathSafe(absPath, dir) {
+		return "", fmt.Errorf("property store: path traversal detected for %q", name)
+	}
+	return absPath, nil
+}
+
+// syncingFile fsyncs before closing so Create's durability guarantee
+// matches what processProperty used to do by hand.
+type syncingFile struct{ *os.File }
+
+func (f *syncingFile) Close() error {
+	if err := f.File.Sync(); err != nil {
+		f.File.Close()
+		return fmt.Errorf("property store: sync: %w", err)
+	}
+	return f.File.Close()
+}
+
+func (l *LocalPropertyStore) Create(name string) (io.WriteCloser, error) {
+	path, err := l.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("property store: mkdir %q: %w", filepath.Dir(path), err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("property store: create %q: %w", path, err)
+	}
+	return &syncingFile{f}, nil
+}
+
+func (l *LocalPropertyStore) Stat(name string) (fs.FileInfo, error) {
+	path, err := l.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(path)
+}
+
+func (l *LocalPropertyStore) URI(name string) string {
+	path, err := l.resolve(name)
+	if err != nil {
+		return ""
+	}
+	return "file://" + path
+}
+
+func (l *LocalPropertyStore) Remove(name string) error {
+	path, err := l.resolve(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// memFileInfo backs Stat for the in-memory and object-storage stores,
+// neither of which has a real fs.FileInfo to hand back.
+type memFileInfo struct {
+	name string
+	size int64
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) Mode() fs.FileMode  { return 0644 }
+func (i memFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memFileInfo) IsDir() bool        { return false }
+func (i memFileInfo) Sys() any           { return nil }
+
+// MemPropertyStore is an in-memory PropertyStore for use in tests that
+// exercise processProperty without touching disk.
+type MemPropertyStore struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemPropertyStore returns an empty MemPropertyStore.
+func NewMemPropertyStore() *MemPropertyStore {
+	return &MemPropertyStore{files: make(map[string][]byte)}
+}
+
+type memWriteCloser struct {
+	store *MemPropertyStore
+	name  string
+	buf   bytes.Buffer
+}
+
+func (w *memWriteCloser) Write(p []byte) (int, error) { return w.buf.Write(p) }
+
+func (w *memWriteCloser) Close() error {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	w.store.files[w.name] = append([]byte(nil), w.buf.Bytes()...)
+	return nil
+}
+
+func (m *MemPropertyStore) Create(name string) (io.WriteCloser, error) {
+	return &memWriteCloser{store: m, name: name}, nil
+}
+
+func (m *MemPropertyStore) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	content, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return memFileInfo{name: name, size: int64(len(content))}, nil
+}
+
+func (m *MemPropertyStore) URI(name string) string {
+	return "mem://" + name
+}
+
+func (m *MemPropertyStore) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, name)
+	return nil
+}
+
+// ObjectStoreClient is the minimal surface an object-storage backend
+// needs in order to back an ObjectPropertyStore. It's deliberately
+// narrow so a thin wrapper around the S3 or GCS SDK client satisfies it
+// without either SDK becoming a dependency of this package.
+type ObjectStoreClient interface {
+	Put(ctx context.Context, key string, content []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Head(ctx context.Context, key string) (size int64, err error)
+	Delete(ctx context.Context, key string) error
+}
+
+// ObjectPropertyStore is a PropertyStore backed by an object-storage
+// bucket. Scheme is the URI scheme reported by URI ("s3" or "gs"); Prefix
+// is prepended to every name so property.json and log output can share a
+// bucket without colliding.
+type ObjectPropertyStore struct {
+	Client ObjectStoreClient
+	Scheme string
+	Bucket string
+	Prefix string
+}
+
+func (o *ObjectPropertyStore) key(name string) string {
+	return path.Join(o.Prefix, name)
+}
+
+type objectWriteCloser struct {
+	store *ObjectPropertyStore
+	key   string
+	buf   bytes.Buffer
+}
+
+func (w *objectWriteCloser) Write(p []byte) (int, error) { return w.buf.Write(p) }
+
+func (w *objectWriteCloser) Close() error {
+	return w.store.Client.Put(context.Background(), w.key, w.buf.Bytes())
+}
+
+func (o *ObjectPropertyStore) Create(name string) (io.WriteCloser, error) {
+	return &objectWriteCloser{store: o, key: o.key(name)}, nil
+}
+
+func (o *ObjectPropertyStore) Stat(name string) (fs.FileInfo, error) {
+	size, err := o.Client.Head(context.Background(), o.key(name))
+	if err != nil {
+		return nil, err
+	}
+	return memFileInfo{name: name, size: size}, nil
+}
+
+func (o *ObjectPropertyStore) URI(name string) string {
+	return fmt.Sprintf("%s://%s/%s", o.Scheme, o.Bucket, o.key(name))
+}
+
+func (o *ObjectPropertyStore) Remove(name string) error {
+	return o.Client.Delete(context.Background(), o.key(name))
+}
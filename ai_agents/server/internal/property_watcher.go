@@ -0,0 +1,370 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// propertyRevalidateInterval is how often PropertyWatcher re-resolves
+// secret references that fsnotify can't watch directly (anything other
+// than the "file" scheme).
+const propertyRevalidateInterval = 30 * time.Second
+
+// resolvedValueSource records where one property's value came from, so a
+// reload can log what changed and GET /channels/:name/properties can
+// report provenance instead of just the opaque resolved value.
+type resolvedValueSource struct {
+	ExtensionName string `json:"extension_name"`
+	Property      string `json:"property"`
+	SecretRef     `json:"ref"`
+	Value         string    `json:"value"`
+	ResolvedAt    time.Time `json:"resolved_at"`
+}
+
+// channelPropertyState is everything PropertyWatcher needs to reconcile
+// one running channel's property.json against its secret references.
+type channelPropertyState struct {
+	mu sync.Mutex
+
+	channelName      string
+	propertyJsonName string
+	worker           *Worker
+	propertyJson     map[string]interface{}
+	sources          []resolvedValueSource
+}
+
+// PropertyWatcher tracks the resolved `${scheme:key}` references behind
+// each running channel's property.json and reconciles them -- rewriting
+// property.json via the PropertyStore and notifying the worker -- when a
+// referenced value changes, so credential rotation doesn't require a
+// stop/start cycle.
+type PropertyWatcher struct {
+	server *HttpServer
+
+	mu       sync.Mutex
+	channels map[string]*channelPropertyState
+
+	watcher  *fsnotify.Watcher
+	fileRefs map[string]map[string]bool // watched path -> channel names referencing it
+}
+
+// newPropertyWatcher wires up an fsnotify watcher for the server. A
+// failure to start fsnotify (e.g. inotify limits, an unsupported OS)
+// only disables file-triggered reloads; periodic revalidation still
+// runs, so it doesn't block server startup.
+func newPropertyWatcher(s *HttpServer) *PropertyWatcher {
+	pw := &PropertyWatcher{
+		server:   s,
+		channels: make(map[string]*channelPropertyState),
+		fileRefs: make(map[string]map[string]bool),
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		slog.Warn("PropertyWatcher: fsnotify unavailable, falling back to periodic revalidation only", "err", err, logTag)
+	} else {
+		pw.watcher = watcher
+		go pw.watchFsEvents()
+	}
+
+	go pw.periodicRevalidate()
+
+	return pw
+}
+
+func (pw *PropertyWatcher) watchFsEvents() {
+	for {
+		select {
+		case event, ok := <-pw.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			pw.reconcilePath(event.Name)
+
+		case err, ok := <-pw.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("PropertyWatcher fsnotify error", "err", err, logTag)
+		}
+	}
+}
+
+func (pw *PropertyWatcher) periodicRevalidate() {
+	ticker := time.NewTicker(propertyRevalidateInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for _, channelName := range pw.channelNames() {
+			if _, failures := pw.Reload(channelName); len(failures) > 0 {
+				slog.Error("PropertyWatcher: periodic revalidation found unresolved references", "channelName", channelName, "failures", failures, logTag)
+			}
+		}
+	}
+}
+
+func (pw *PropertyWatcher) channelNames() []string {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	names := make([]string, 0, len(pw.channels))
+	for name := range pw.channels {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Track registers channelName's just-started property state so later
+// secret changes can be reconciled without a restart.
+func (pw *PropertyWatcher) Track(channelName, propertyJsonName string, worker *Worker, propertyJson map[string]interface{}, sources []resolvedValueSource) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	pw.channels[channelName] = &channelPropertyState{
+		channelName:      channelName,
+		propertyJsonName: propertyJsonName,
+		worker:           worker,
+		propertyJson:     propertyJson,
+		sources:          sources,
+	}
+
+	if pw.watcher == nil {
+		return
+	}
+	for _, src := range sources {
+		if src.Scheme != "file" {
+			continue
+		}
+		pw.addFileRefLocked(src.Key, channelName)
+	}
+}
+
+func (pw *PropertyWatcher) addFileRefLocked(path, channelName string) {
+	if pw.fileRefs[path] == nil {
+		pw.fileRefs[path] = make(map[string]bool)
+		if err := pw.watcher.Add(path); err != nil {
+			slog.Warn("PropertyWatcher: failed to watch secret file", "path", path, "err", err, logTag)
+		}
+	}
+	pw.fileRefs[path][channelName] = true
+}
+
+// Forget stops tracking channelName, e.g. once it's been stopped.
+func (pw *PropertyWatcher) Forget(channelName string) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	delete(pw.channels, channelName)
+	for path, channels := range pw.fileRefs {
+		delete(channels, channelName)
+		if len(channels) == 0 {
+			delete(pw.fileRefs, path)
+			if pw.watcher != nil {
+				pw.watcher.Remove(path)
+			}
+		}
+	}
+}
+
+func (pw *PropertyWatcher) reconcilePath(path string) {
+	pw.mu.Lock()
+	channelsForPath := pw.fileRefs[path]
+	channels := make([]string, 0, len(channelsForPath))
+	for name := range channelsForPath {
+		channels = append(channels, name)
+	}
+	pw.mu.Unlock()
+
+	for _, channelName := range channels {
+		if _, failures := pw.Reload(channelName); len(failures) > 0 {
+			slog.Error("PropertyWatcher: file-triggered reload found unresolved references", "channelName", channelName, "path", path, "failures", failures, logTag)
+		}
+	}
+}
+
+// Snapshot returns the resolved sources currently backing channelName's
+// property.json, for GET /channels/:name/properties.
+func (pw *PropertyWatcher) Snapshot(channelName string) ([]resolvedValueSource, bool) {
+	pw.mu.Lock()
+	state, ok := pw.channels[channelName]
+	pw.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	sources := make([]resolvedValueSource, len(state.sources))
+	copy(sources, state.sources)
+	return sources, true
+}
+
+// Reload re-resolves every secret reference backing channelName's
+// property.json. If any resolved value changed, it rewrites
+// property.json via the PropertyStore and dispatches a
+// graph.property.updated cmd to the running worker. Unresolved
+// references are returned as failures instead of applying a partial
+// update.
+func (pw *PropertyWatcher) Reload(channelName string) (changed []resolvedValueSource, failures []secretResolutionFailure) {
+	pw.mu.Lock()
+	state, ok := pw.channels[channelName]
+	pw.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	values := make([]string, len(state.sources))
+	for i, src := range state.sources {
+		pw.server.secrets.invalidate(src.Scheme, src.Key)
+
+		value, found, err := pw.server.secrets.resolveCached(src.SecretRef)
+		if err != nil {
+			failures = append(failures, secretResolutionFailure{Raw: src.Raw, Scheme: src.Scheme, Key: src.Key, Err: err.Error()})
+			continue
+		}
+		if !found {
+			if src.HasDefault {
+				value = src.Default
+			} else {
+				failures = append(failures, secretResolutionFailure{Raw: src.Raw, Scheme: src.Scheme, Key: src.Key})
+				continue
+			}
+		}
+		values[i] = value
+	}
+
+	if len(failures) > 0 {
+		return nil, failures
+	}
+
+	for i, src := range state.sources {
+		value := values[i]
+		if value == src.Value {
+			continue
+		}
+
+		if !setNodeProperty(state.propertyJson, src.ExtensionName, src.Property, value) {
+			slog.Warn("PropertyWatcher: resolved node no longer present in property.json", "channelName", channelName, "extensionName", src.ExtensionName, "property", src.Property, logTag)
+			continue
+		}
+
+		slog.Info("PropertyWatcher: secret value changed", "channelName", channelName, "extensionName", src.ExtensionName, "property", src.Property, "scheme", src.Scheme, logTag)
+		state.sources[i].Value = value
+		state.sources[i].ResolvedAt = time.Now()
+		changed = append(changed, state.sources[i])
+	}
+
+	if len(changed) == 0 {
+		return changed, failures
+	}
+
+	if err := pw.rewritePropertyJson(state); err != nil {
+		slog.Error("PropertyWatcher: failed to rewrite property.json", "channelName", channelName, "err", err, logTag)
+		failures = append(failures, secretResolutionFailure{Err: err.Error()})
+		return changed, failures
+	}
+
+	if err := pw.notifyWorker(state, changed); err != nil {
+		slog.Error("PropertyWatcher: failed to notify worker of property update", "channelName", channelName, "err", err, logTag)
+	}
+
+	return changed, failures
+}
+
+func (pw *PropertyWatcher) rewritePropertyJson(state *channelPropertyState) error {
+	content, err := json.MarshalIndent(state.propertyJson, "", "  ")
+	if err != nil {
+		return fmt.Errorf("property watcher: marshal property.json: %w", err)
+	}
+
+	f, err := pw.server.store.Create(state.propertyJsonName)
+	if err != nil {
+		return fmt.Errorf("property watcher: open property.json: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(content); err != nil {
+		return fmt.Errorf("property watcher: write property.json: %w", err)
+	}
+	return nil
+}
+
+// notifyWorker dispatches a graph.property.updated cmd over the existing
+// worker.update() IPC path so the running graph can pick up the new
+// values without a restart.
+func (pw *PropertyWatcher) notifyWorker(state *channelPropertyState, changed []resolvedValueSource) error {
+	properties := make(map[string]interface{}, len(changed))
+	for _, c := range changed {
+		properties[c.ExtensionName+"."+c.Property] = c.Value
+	}
+
+	return state.worker.update(&WorkerUpdateReq{
+		ChannelName: state.channelName,
+		Ten: &WorkerUpdateReqTen{
+			Name: "graph.property.updated",
+			Type: "cmd",
+		},
+		Properties: properties,
+	})
+}
+
+// setNodeProperty finds extensionName's property in propertyJson's
+// (already graph-filtered) predefined_graphs section and sets it to
+// value, returning false if the node or property no longer exists.
+func setNodeProperty(propertyJson map[string]interface{}, extensionName, property string, value string) bool {
+	tenSection, ok := propertyJson["ten"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	graphs, ok := tenSection["predefined_graphs"].([]interface{})
+	if !ok {
+		return false
+	}
+
+	for _, graph := range graphs {
+		graphMap, ok := graph.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		graphData, ok := graphMap["graph"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		nodes, ok := graphData["nodes"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, node := range nodes {
+			nodeMap, ok := node.(map[string]interface{})
+			if !ok || nodeMap["name"] != extensionName {
+				continue
+			}
+			properties, ok := nodeMap["property"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if _, exists := properties[property]; !exists {
+				continue
+			}
+			properties[property] = value
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,323 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RemoteFetchConfig bounds processProperty's remote GraphURL /
+// PropertyOverlayURL fetches. The zero value is safe but permissive
+// (https-only, no host allow/deny list) -- set Disabled to opt out
+// entirely on deployments that don't want the process making outbound
+// requests on a caller's behalf at all.
+type RemoteFetchConfig struct {
+	// Disabled rejects any /start request carrying GraphURL or
+	// PropertyOverlayURL instead of fetching it.
+	Disabled bool
+
+	// AllowedHostSuffixes, if non-empty, restricts fetches to hosts
+	// ending in one of these suffixes (e.g. ".trusted-partner.com").
+	AllowedHostSuffixes []string
+
+	// DeniedHostSuffixes is checked before AllowedHostSuffixes and wins
+	// regardless of the allow list.
+	DeniedHostSuffixes []string
+
+	// MaxResponseBytes caps the response body. Zero means
+	// defaultRemoteFetchMaxResponseBytes.
+	MaxResponseBytes int64
+
+	// MaxRedirects caps how many redirects are followed, each one
+	// re-validated against the SSRF and host-suffix checks. Zero means
+	// defaultRemoteFetchMaxRedirects.
+	MaxRedirects int
+
+	// TimeoutSeconds bounds the whole fetch, including redirects. Zero
+	// means defaultRemoteFetchTimeoutSeconds.
+	TimeoutSeconds int
+}
+
+const (
+	defaultRemoteFetchMaxResponseBytes = 1 << 20 // 1MiB
+	defaultRemoteFetchMaxRedirects     = 3
+	defaultRemoteFetchTimeoutSeconds   = 10
+)
+
+// remoteFetchCacheTTL bounds how long a fetched GraphURL/PropertyOverlayURL
+// response is reused across /start requests before being re-fetched.
+const remoteFetchCacheTTL = 30 * time.Second
+
+func (c RemoteFetchConfig) maxResponseBytes() int64 {
+	if c.MaxResponseBytes > 0 {
+		return c.MaxResponseBytes
+	}
+	return defaultRemoteFetchMaxResponseBytes
+}
+
+func (c RemoteFetchConfig) maxRedirects() int {
+	if c.MaxRedirects > 0 {
+		return c.MaxRedirects
+	}
+	return defaultRemoteFetchMaxRedirects
+}
+
+func (c RemoteFetchConfig) timeout() time.Duration {
+	if c.TimeoutSeconds > 0 {
+		return time.Duration(c.TimeoutSeconds) * time.Second
+	}
+	return defaultRemoteFetchTimeoutSeconds * time.Second
+}
+
+// remoteFetchCacheEntry is one cached GraphURL/PropertyOverlayURL
+// response, keyed by URL; hash is the SHA-256 of the fetched body. It is
+// stored with the entry but not consulted by fetchRemoteJSON.
+type remoteFetchCacheEntry struct {
+	hash      string
+	content   map[string]interface{}
+	fetchedAt time.Time
+}
+
+// remoteFetchCache avoids re-fetching (and re-validating the SSRF checks
+// for) the same URL on every /start within remoteFetchCacheTTL.
+type remoteFetchCache struct {
+	mu      sync.Mutex
+	entries map[string]remoteFetchCacheEntry
+}
+
+func newRemoteFetchCache() *remoteFetchCache {
+	return &remoteFetchCache{entries: make(map[string]remoteFetchCacheEntry)}
+}
+
+// cgnatBlock is the carrier-grade NAT range (RFC 6598), not covered by
+// net.IP.IsPrivate().
+var cgnatBlock = mustParseCIDR("100.64.0.0/10")
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// isSSRFSafeIP rejects loopback, link-local, RFC1918, CGNAT, and IPv6
+// ULA/link-local addresses -- everything that would let a /start caller
+// reach this process's own host or internal network via a crafted
+// GraphURL/PropertyOverlayURL.
+func isSSRFSafeIP(ip net.IP) bool {
+	switch {
+	case ip.IsLoopback(),
+		ip.IsLinkLocalUnicast(),
+		ip.IsLinkLocalMulticast(),
+		ip.IsMulticast(),
+		ip.IsUnspecified(),
+		ip.IsPrivate(): // RFC1918 + IPv6 ULA (fc00::/7)
+		return false
+	}
+	return !cgnatBlock.Contains(ip)
+}
+
+// hostAllowed applies DeniedHostSuffixes then AllowedHostSuffixes; an
+// empty allow list means "allow anything not denied".
+func hostAllowed(host string, cfg RemoteFetchConfig) bool {
+	host = strings.ToLower(host)
+	for _, suffix := range cfg.DeniedHostSuffixes {
+		if strings.HasSuffix(host, strings.ToLower(suffix)) {
+			return false
+		}
+	}
+	if len(cfg.AllowedHostSuffixes) == 0 {
+		return true
+	}
+	for _, suffix := range cfg.AllowedHostSuffixes {
+		if strings.HasSuffix(host, strings.ToLower(suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRemoteFetchURL rejects non-https URLs and hosts the
+// allow/deny list already rules out, before a single connection is
+// attempted.
+func validateRemoteFetchURL(rawURL string, cfg RemoteFetchConfig) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("remote fetch: invalid URL: %w", err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("remote fetch: only https URLs are allowed, got %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("remote fetch: URL has no host")
+	}
+	if !hostAllowed(u.Hostname(), cfg) {
+		return nil, fmt.Errorf("remote fetch: host %q is not allowed", u.Hostname())
+	}
+	return u, nil
+}
+
+// newSSRFSafeClient builds an *http.Client whose DialContext resolves the
+// hostname itself and connects directly to the resolved IP after checking
+// it with isSSRFSafeIP -- so the safety check applies to the address
+// actually dialed, not a separately resolved address that could differ by
+// the time the real connection is made (DNS rebinding). CheckRedirect
+// re-applies the scheme/host checks on every hop and bounds the hop count;
+// each redirect's connection still goes through the same DialContext.
+func newSSRFSafeClient(cfg RemoteFetchConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.timeout()}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, fmt.Errorf("remote fetch: %w", err)
+			}
+
+			ipAddrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+			if err != nil {
+				return nil, fmt.Errorf("remote fetch: resolve %q: %w", host, err)
+			}
+
+			for _, ipAddr := range ipAddrs {
+				if !isSSRFSafeIP(ipAddr.IP) {
+					continue
+				}
+				return dialer.DialContext(ctx, network, net.JoinHostPort(ipAddr.IP.String(), port))
+			}
+			return nil, fmt.Errorf("remote fetch: host %q has no externally-routable address", host)
+		},
+	}
+
+	return &http.Client{
+		Timeout:   cfg.timeout(),
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= cfg.maxRedirects() {
+				return fmt.Errorf("remote fetch: too many redirects (max %d)", cfg.maxRedirects())
+			}
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("remote fetch: redirect to non-https URL %q", req.URL)
+			}
+			if !hostAllowed(req.URL.Hostname(), cfg) {
+				return fmt.Errorf("remote fetch: redirect host %q is not allowed", req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+}
+
+// fetchRemoteJSON fetches url as a JSON object, enforcing the SSRF
+// validation, content-type allowlist, and size limit from
+// s.config.RemoteFetch, and serves a cached copy when one is still fresh.
+func (s *HttpServer) fetchRemoteJSON(rawURL, requestId string) (map[string]interface{}, error) {
+	cfg := s.config.RemoteFetch
+	if cfg.Disabled {
+		return nil, fmt.Errorf("remote fetch: disabled by configuration")
+	}
+
+	u, err := validateRemoteFetchURL(rawURL, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s.remoteFetch.mu.Lock()
+	if cached, ok := s.remoteFetch.entries[u.String()]; ok && time.Since(cached.fetchedAt) < remoteFetchCacheTTL {
+		s.remoteFetch.mu.Unlock()
+		return cached.content, nil
+	}
+	s.remoteFetch.mu.Unlock()
+
+	client := newSSRFSafeClient(cfg)
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("remote fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote fetch: unexpected status %d from %q", resp.StatusCode, u)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "application/json") {
+		return nil, fmt.Errorf("remote fetch: unexpected content-type %q from %q", contentType, u)
+	}
+
+	limit := cfg.maxResponseBytes()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("remote fetch: read body: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("remote fetch: response from %q exceeds %d byte limit", u, limit)
+	}
+
+	var content map[string]interface{}
+	if err := json.Unmarshal(body, &content); err != nil {
+		return nil, fmt.Errorf("remote fetch: invalid JSON from %q: %w", u, err)
+	}
+
+	hash := sha256.Sum256(body)
+	s.remoteFetch.mu.Lock()
+	s.remoteFetch.entries[u.String()] = remoteFetchCacheEntry{
+		hash:      hex.EncodeToString(hash[:]),
+		content:   content,
+		fetchedAt: time.Now(),
+	}
+	s.remoteFetch.mu.Unlock()
+
+	slog.Info("remote fetch succeeded", "url", u.String(), "bytes", len(body), "requestId", requestId, logTag)
+	return content, nil
+}
+
+// fetchRemoteGraph fetches a single predefined_graphs entry
+// (`{"name": ..., "graph": {...}}`) from graphURL, for StartReq.GraphURL.
+func (s *HttpServer) fetchRemoteGraph(graphURL, requestId string) (map[string]interface{}, error) {
+	content, err := s.fetchRemoteJSON(graphURL, requestId)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := content["graph"].(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("remote fetch: %q is missing a \"graph\" object", graphURL)
+	}
+	return content, nil
+}
+
+// fetchRemotePropertyOverlay fetches a StartReq.Properties-shaped overlay
+// (extension name -> property map) from overlayURL, for
+// StartReq.PropertyOverlayURL.
+func (s *HttpServer) fetchRemotePropertyOverlay(overlayURL, requestId string) (map[string]map[string]interface{}, error) {
+	content, err := s.fetchRemoteJSON(overlayURL, requestId)
+	if err != nil {
+		return nil, err
+	}
+
+	overlay := make(map[string]map[string]interface{}, len(content))
+	for extensionName, props := range content {
+		propMap, ok := props.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("remote fetch: %q: extension %q is not a property object", overlayURL, extensionName)
+		}
+		overlay[extensionName] = propMap
+	}
+	return overlay, nil
+}
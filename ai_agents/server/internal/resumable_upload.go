@@ -0,0 +1,359 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// uploadSessionExpiry bounds how long an initiated-but-unfinished upload
+// session is kept around before it is considered abandoned.
+const uploadSessionExpiry = 24 * time.Hour
+
+// maxUploadsPerChannel caps the number of concurrent upload sessions a
+// single channel may have open, so one client can't exhaust disk/memory.
+const maxUploadsPerChannel = 3
+
+// uploadDiskQuotaBytes caps the total size a single upload session may
+// reach.
+const uploadDiskQuotaBytes = 2 << 30 // 2 GiB
+
+// UploadSession tracks one resumable upload's server-side state: how many
+// bytes have landed in the staging file so far, and enough metadata to
+// finalize it into the existing worker.update(...file_chunk...) path.
+//
+// Sessions are kept in-memory and persisted to a sidecar ".upload.json"
+// file next to the staging file on every offset change. Nothing in this
+// package reads the sidecars back, so after a restart of the HTTP server
+// the sessions are no longer in uploadSessions and their ids return 404.
+type UploadSession struct {
+	ID          string    `json:"id"`
+	ChannelName string    `json:"channel_name"`
+	FileName    string    `json:"file_name"`
+	TotalSize   int64     `json:"total_size"`
+	SHA256      string    `json:"sha256,omitempty"`
+	Offset      int64     `json:"offset"`
+	StagingPath string    `json:"staging_path"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Finalized   bool      `json:"finalized"`
+
+	mu sync.Mutex
+}
+
+type uploadSessionRegistry struct {
+	mu       sync.RWMutex
+	sessions map[string]*UploadSession
+}
+
+var uploadSessions = &uploadSessionRegistry{sessions: make(map[string]*UploadSession)}
+
+func (r *uploadSessionRegistry) Set(s *UploadSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[s.ID] = s
+}
+
+func (r *uploadSessionRegistry) Get(id string) (*UploadSession, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.sessions[id]
+	if ok && time.Now().After(s.ExpiresAt) {
+		return nil, false
+	}
+	return s, ok
+}
+
+func (r *uploadSessionRegistry) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, id)
+}
+
+func (r *uploadSessionRegistry) countForChannel(channelName string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for _, s := range r.sessions {
+		if s.ChannelName == channelName {
+			n++
+		}
+	}
+	return n
+}
+
+// sidecarPath returns where a session's resumable metadata is persisted.
+func (s *UploadSession) sidecarPath() string {
+	return s.StagingPath + ".upload.json"
+}
+
+func (s *UploadSession) persist() error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.sidecarPath(), b, 0o600)
+}
+
+// InitUploadReq is the body of POST /vector_document/uploads.
+type InitUploadReq struct {
+	ChannelName string `json:"channel_name" binding:"required"`
+	FileName    string `json:"file_name" binding:"required"`
+	TotalSize   int64  `json:"total_size" binding:"required"`
+	SHA256      string `json:"sha256,omitempty"`
+}
+
+// handlerVectorDocumentUploadInit starts a resumable upload session and
+// returns its id plus a Location URL the client PATCHes chunks to.
+func (s *HttpServer) handlerVectorDocumentUploadInit(c *gin.Context) {
+	var req InitUploadReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.output(c, codeErrParamsInvalid, http.StatusBadRequest)
+		return
+	}
+
+	if !workers.Contains(req.ChannelName) {
+		s.output(c, codeErrChannelNotExisted, http.StatusBadRequest)
+		return
+	}
+
+	if req.TotalSize <= 0 || req.TotalSize > uploadDiskQuotaBytes {
+		s.output(c, codeErrParamsInvalid, http.StatusBadRequest)
+		return
+	}
+
+	if uploadSessions.countForChannel(req.ChannelName) >= maxUploadsPerChannel {
+		s.output(c, codeErrWorkersLimit, http.StatusTooManyRequests)
+		return
+	}
+
+	safeChannelName, err := sanitizeChannelName(req.ChannelName)
+	if err != nil {
+		s.output(c, codeErrParamsInvalid, http.StatusBadRequest)
+		return
+	}
+
+	id := uuid.NewString()
+	stagingPath := filepath.Join(s.config.LogPath, fmt.Sprintf("upload-%s-%s.part", safeChannelName, id))
+
+	file, err := os.Create(stagingPath)
+	if err != nil {
+		s.output(c, codeErrSaveFileFailed, http.StatusInternalServerError)
+		return
+	}
+	file.Close()
+
+	session := &UploadSession{
+		ID:          id,
+		ChannelName: req.ChannelName,
+		FileName:    filepath.Base(req.FileName),
+		TotalSize:   req.TotalSize,
+		SHA256:      req.SHA256,
+		StagingPath: stagingPath,
+		CreatedAt:   time.Now(),
+		ExpiresAt:   time.Now().Add(uploadSessionExpiry),
+	}
+	if err := session.persist(); err != nil {
+		s.output(c, codeErrSaveFileFailed, http.StatusInternalServerError)
+		return
+	}
+	uploadSessions.Set(session)
+
+	location := fmt.Sprintf("/vector_document/uploads/%s", id)
+	c.Header("Location", location)
+	s.output(c, codeSuccess, map[string]any{"upload_id": id, "location": location}, http.StatusAccepted)
+}
+
+// contentRangePattern parses "bytes X-Y/Z" Content-Range headers.
+var contentRangePattern = regexp.MustCompile(`^bytes (\d+)-(\d+)/(\d+)$`)
+
+// handlerVectorDocumentUploadChunk appends a byte range to the staging
+// file and reports the new offset in a Range response header, so the
+// client knows where to resume from after a disconnect.
+func (s *HttpServer) handlerVectorDocumentUploadChunk(c *gin.Context) {
+	session, ok := uploadSessions.Get(c.Param("id"))
+	if !ok {
+		s.output(c, codeErrChannelNotExisted, http.StatusNotFound)
+		return
+	}
+
+	matches := contentRangePattern.FindStringSubmatch(c.GetHeader("Content-Range"))
+	if matches == nil {
+		s.output(c, codeErrParamsInvalid, http.StatusBadRequest)
+		return
+	}
+	start, _ := strconv.ParseInt(matches[1], 10, 64)
+	end, _ := strconv.ParseInt(matches[2], 10, 64)
+	total, _ := strconv.ParseInt(matches[3], 10, 64)
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if session.Finalized {
+		s.output(c, codeErrParamsInvalid, http.StatusConflict)
+		return
+	}
+	if total != session.TotalSize || start != session.Offset || end < start {
+		s.output(c, codeErrParamsInvalid, http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	if end+1 > session.TotalSize || end+1 > uploadDiskQuotaBytes {
+		s.output(c, codeErrParamsInvalid, http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	file, err := os.OpenFile(session.StagingPath, os.O_WRONLY, 0o600)
+	if err != nil {
+		s.output(c, codeErrSaveFileFailed, http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		s.output(c, codeErrSaveFileFailed, http.StatusInternalServerError)
+		return
+	}
+
+	declared := end - start + 1
+	written, err := io.Copy(file, io.LimitReader(c.Request.Body, declared))
+	if err != nil {
+		s.output(c, codeErrSaveFileFailed, http.StatusInternalServerError)
+		return
+	}
+	if written != declared {
+		s.output(c, codeErrParamsInvalid, http.StatusBadRequest)
+		return
+	}
+
+	session.Offset = start + written
+	if err := session.persist(); err != nil {
+		s.output(c, codeErrSaveFileFailed, http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Range", fmt.Sprintf("bytes=0-%d", session.Offset-1))
+	s.output(c, codeSuccess, map[string]any{"offset": session.Offset}, http.StatusNoContent)
+}
+
+// handlerVectorDocumentUploadHead reports the current offset so a client
+// can resume after losing its connection mid-upload.
+func (s *HttpServer) handlerVectorDocumentUploadHead(c *gin.Context) {
+	session, ok := uploadSessions.Get(c.Param("id"))
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	session.mu.Lock()
+	offset := session.Offset
+	session.mu.Unlock()
+
+	c.Header("Range", fmt.Sprintf("bytes=0-%d", offset-1))
+	c.Status(http.StatusOK)
+}
+
+// handlerVectorDocumentUploadFinalize verifies the digest (if one was
+// supplied at init time) and hands the finished file off to the existing
+// worker.update(...file_chunk...) path.
+func (s *HttpServer) handlerVectorDocumentUploadFinalize(c *gin.Context) {
+	session, ok := uploadSessions.Get(c.Param("id"))
+	if !ok {
+		s.output(c, codeErrChannelNotExisted, http.StatusNotFound)
+		return
+	}
+
+	session.mu.Lock()
+	if session.Offset != session.TotalSize {
+		session.mu.Unlock()
+		s.output(c, codeErrParamsInvalid, http.StatusConflict)
+		return
+	}
+
+	if session.SHA256 != "" {
+		sum, err := sha256File(session.StagingPath)
+		if err != nil || !strings.EqualFold(sum, session.SHA256) {
+			session.mu.Unlock()
+			s.output(c, codeErrParamsInvalid, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+	session.Finalized = true
+	_ = session.persist()
+	session.mu.Unlock()
+
+	if !workers.Contains(session.ChannelName) {
+		s.output(c, codeErrChannelNotExisted, http.StatusBadRequest)
+		return
+	}
+
+	collection := fmt.Sprintf("a%s_%d", session.ChannelName, time.Now().UnixNano())
+	worker := workers.Get(session.ChannelName).(*Worker)
+
+	ctx, cancel := contextFromRequest(c, "")
+	op := newOperation("vector_document_upload", session.ChannelName, "", cancel)
+	go func() {
+		defer cancel()
+		op.setRunning()
+		err := worker.updateCtx(ctx, &WorkerUpdateReq{
+			ChannelName: session.ChannelName,
+			Collection:  collection,
+			FileName:    session.FileName,
+			Path:        session.StagingPath,
+			Ten: &WorkerUpdateReqTen{
+				Name: "file_chunk",
+				Type: "cmd",
+			},
+		})
+		if err != nil {
+			op.finish(OperationFailure, err)
+			return
+		}
+		op.finish(OperationSuccess, nil)
+
+		uploadSessions.Remove(session.ID)
+		os.Remove(session.sidecarPath())
+	}()
+
+	s.output(c, codeSuccess, map[string]any{
+		"channel_name": session.ChannelName,
+		"collection":   collection,
+		"file_name":    session.FileName,
+		"operation_id": op.ID,
+		"resource_url": op.ResourceURL,
+	}, http.StatusAccepted)
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
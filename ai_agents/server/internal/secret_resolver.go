@@ -0,0 +1,292 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretResolver resolves a single `${scheme:key|default}` reference.
+// `key` is everything between the scheme's colon and the optional
+// "|default" suffix (for the vault/aws-sm/gcp-sm schemes this includes the
+// "#field" selector, which is scheme-specific and left for the resolver to
+// parse). ok is false when the reference is legitimately absent (as
+// opposed to err, which signals the resolver itself failed).
+type SecretResolver interface {
+	Resolve(scheme, key string) (value string, ok bool, err error)
+}
+
+// secretResolverCacheTTL bounds how long a resolved value is reused
+// before being looked up again, so a long-lived server doesn't serve a
+// stale secret indefinitely but also doesn't hit slow backends (vault,
+// aws-sm, gcp-sm) on every single /start.
+const secretResolverCacheTTL = 60 * time.Second
+
+// secretRefPattern matches `${scheme:body|default}`, with an optional
+// "|default" suffix (which may be empty, i.e. just "|").
+var secretRefPattern = regexp.MustCompile(`\$\{([a-zA-Z0-9_-]+):([^}|]+)(\|[^}]*)?\}`)
+
+// SecretRef is one parsed `${scheme:key|default}` occurrence.
+type SecretRef struct {
+	Raw        string
+	Scheme     string
+	Key        string
+	Default    string
+	HasDefault bool
+}
+
+// parseSecretRefs extracts every `${scheme:key|default}` occurrence in s.
+func parseSecretRefs(s string) []SecretRef {
+	matches := secretRefPattern.FindAllStringSubmatch(s, -1)
+	refs := make([]SecretRef, 0, len(matches))
+	for _, m := range matches {
+		ref := SecretRef{Raw: m[0], Scheme: m[1], Key: m[2]}
+		if len(m) >= 4 && m[3] != "" {
+			ref.HasDefault = true
+			ref.Default = strings.TrimPrefix(m[3], "|")
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// EnvResolver resolves `${env:NAME|default}` against the process
+// environment, the original (and only) scheme supported before this file
+// existed.
+type EnvResolver struct{}
+
+func (EnvResolver) Resolve(_ string, key string) (string, bool, error) {
+	v, ok := os.LookupEnv(key)
+	return v, ok, nil
+}
+
+// FileResolver resolves `${file:/path/to/secret|default}` by reading the
+// file's contents, trimming a single trailing newline (the common
+// "echo secret > file" convention).
+type FileResolver struct{}
+
+func (FileResolver) Resolve(_ string, key string) (string, bool, error) {
+	content, err := os.ReadFile(key)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("secret: read file %q: %w", key, err)
+	}
+	return strings.TrimSuffix(string(content), "\n"), true, nil
+}
+
+// ChainResolver tries each of its resolvers in order, for schemes that
+// have more than one valid backend (e.g. falling back from vault to env
+// during a migration).
+type ChainResolver struct {
+	Resolvers []SecretResolver
+}
+
+func (c ChainResolver) Resolve(scheme, key string) (string, bool, error) {
+	for _, r := range c.Resolvers {
+		v, ok, err := r.Resolve(scheme, key)
+		if err != nil {
+			return "", false, err
+		}
+		if ok {
+			return v, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// secretResolverRegistry holds the scheme -> resolver mapping used when
+// substituting property.json values, plus a short-lived cache of resolved
+// values keyed by "scheme:key".
+type secretResolverRegistry struct {
+	mu        sync.RWMutex
+	resolvers map[string]SecretResolver
+
+	cacheMu sync.Mutex
+	cache   map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	value    string
+	ok       bool
+	resolved time.Time
+}
+
+// newSecretResolverRegistry builds a registry with the built-in env and
+// file resolvers already registered.
+func newSecretResolverRegistry() *secretResolverRegistry {
+	return &secretResolverRegistry{
+		resolvers: map[string]SecretResolver{
+			"env":  EnvResolver{},
+			"file": FileResolver{},
+		},
+		cache: make(map[string]cachedSecret),
+	}
+}
+
+// Register adds or replaces the resolver for scheme.
+func (r *secretResolverRegistry) Register(scheme string, resolver SecretResolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolvers[scheme] = resolver
+}
+
+func (r *secretResolverRegistry) resolverFor(scheme string) (SecretResolver, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	res, ok := r.resolvers[scheme]
+	return res, ok
+}
+
+// resolveCached resolves ref, serving a cached value when one is present
+// and still within secretResolverCacheTTL.
+func (r *secretResolverRegistry) resolveCached(ref SecretRef) (string, bool, error) {
+	cacheKey := ref.Scheme + ":" + ref.Key
+
+	r.cacheMu.Lock()
+	if cached, ok := r.cache[cacheKey]; ok && time.Since(cached.resolved) < secretResolverCacheTTL {
+		r.cacheMu.Unlock()
+		return cached.value, cached.ok, nil
+	}
+	r.cacheMu.Unlock()
+
+	resolver, ok := r.resolverFor(ref.Scheme)
+	if !ok {
+		return "", false, fmt.Errorf("secret: no resolver registered for scheme %q", ref.Scheme)
+	}
+
+	value, ok, err := resolver.Resolve(ref.Scheme, ref.Key)
+	if err != nil {
+		return "", false, err
+	}
+
+	r.cacheMu.Lock()
+	r.cache[cacheKey] = cachedSecret{value: value, ok: ok, resolved: time.Now()}
+	r.cacheMu.Unlock()
+
+	return value, ok, nil
+}
+
+// invalidate drops scheme:key's cached value, forcing the next
+// resolveCached call to hit the resolver again; used by PropertyWatcher's
+// periodic revalidation so it doesn't just keep re-reading the cache.
+func (r *secretResolverRegistry) invalidate(scheme, key string) {
+	r.cacheMu.Lock()
+	delete(r.cache, scheme+":"+key)
+	r.cacheMu.Unlock()
+}
+
+// secretResolutionFailure records one `${scheme:key}` reference that
+// could not be resolved, for the 4xx response body: either its resolver
+// returned an error (Err is set, and any default is not used), or the
+// value was absent and the reference had no default.
+type secretResolutionFailure struct {
+	Raw    string `json:"ref"`
+	Scheme string `json:"scheme"`
+	Key    string `json:"key"`
+	Err    string `json:"error,omitempty"`
+}
+
+// appliedSecretRef records a reference that resolveAllDetailed actually
+// substituted (whether from a resolver or a default), so callers that
+// need provenance (PropertyWatcher) don't have to re-parse the string.
+type appliedSecretRef struct {
+	Ref   SecretRef
+	Value string
+}
+
+// resolveAll finds every `${scheme:key|default}` reference in s and
+// substitutes resolved values in parallel. References with no default
+// that fail to resolve are collected and returned as failures instead of
+// silently leaving the placeholder in place; the caller should treat any
+// non-empty failures slice as fatal.
+func (r *secretResolverRegistry) resolveAll(s string) (string, []secretResolutionFailure) {
+	out, _, failures := r.resolveAllDetailed(s)
+	return out, failures
+}
+
+// resolveAllDetailed is resolveAll plus the per-reference values it
+// applied, for callers that need to record where a resolved value came
+// from.
+func (r *secretResolverRegistry) resolveAllDetailed(s string) (string, []appliedSecretRef, []secretResolutionFailure) {
+	refs := parseSecretRefs(s)
+	if len(refs) == 0 {
+		return s, nil, nil
+	}
+
+	type result struct {
+		ref   SecretRef
+		value string
+		ok    bool
+		err   error
+	}
+
+	results := make([]result, len(refs))
+	var wg sync.WaitGroup
+	for i, ref := range refs {
+		i, ref := i, ref
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, ok, err := r.resolveCached(ref)
+			results[i] = result{ref: ref, value: value, ok: ok, err: err}
+		}()
+	}
+	wg.Wait()
+
+	out := s
+	var applied []appliedSecretRef
+	var failures []secretResolutionFailure
+	for _, res := range results {
+		switch {
+		case res.err != nil:
+			failures = append(failures, secretResolutionFailure{Raw: res.ref.Raw, Scheme: res.ref.Scheme, Key: res.ref.Key, Err: res.err.Error()})
+		case res.ok:
+			out = strings.ReplaceAll(out, res.ref.Raw, res.value)
+			applied = append(applied, appliedSecretRef{Ref: res.ref, Value: res.value})
+		case res.ref.HasDefault:
+			out = strings.ReplaceAll(out, res.ref.Raw, res.ref.Default)
+			applied = append(applied, appliedSecretRef{Ref: res.ref, Value: res.ref.Default})
+		default:
+			failures = append(failures, secretResolutionFailure{Raw: res.ref.Raw, Scheme: res.ref.Scheme, Key: res.ref.Key})
+		}
+	}
+
+	return out, applied, failures
+}
+
+// SecretResolutionError is returned by processProperty when one or more
+// `${scheme:key}` references in property.json have no default and
+// couldn't be resolved, so the caller can surface a structured 4xx
+// instead of the generic "process property failed" 500.
+type SecretResolutionError struct {
+	Failures []secretResolutionFailure
+}
+
+func (e *SecretResolutionError) Error() string {
+	return fmt.Sprintf("secret: %d unresolved reference(s)", len(e.Failures))
+}
+
+// HttpServerOption configures optional HttpServer behavior at
+// construction time, e.g. NewHttpServer(cfg, WithSecretResolver("vault", v)).
+type HttpServerOption func(*HttpServer)
+
+// WithSecretResolver registers resolver for scheme, so property.json
+// values like ${vault:secret/data/foo#bar} can be resolved in addition to
+// the built-in env and file schemes.
+func WithSecretResolver(scheme string, resolver SecretResolver) HttpServerOption {
+	return func(s *HttpServer) {
+		s.secrets.Register(scheme, resolver)
+	}
+}
@@ -0,0 +1,163 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRequestTimeout bounds how long a mutating request is allowed to
+// run when the client doesn't supply X-Request-Timeout or a "deadline"
+// field.
+const defaultRequestTimeout = 30 * time.Second
+
+// maxRequestTimeout is the hard ceiling on a client-requested deadline, so
+// a single caller can't pin a worker RPC open indefinitely.
+const maxRequestTimeout = 5 * time.Minute
+
+// killGracePeriod is how long startCtx/stopCtx/updateCtx wait after a
+// context is cancelled before escalating from SIGTERM to SIGKILL on the
+// spawned process.
+const killGracePeriod = 5 * time.Second
+
+// requestDeadline resolves the effective timeout for a mutating request:
+// the X-Request-Timeout header, the "deadline" JSON field (if the handler
+// parsed one), or defaultRequestTimeout, capped at maxRequestTimeout.
+func requestDeadline(c *gin.Context, jsonDeadline string) time.Duration {
+	raw := c.GetHeader("X-Request-Timeout")
+	if raw == "" {
+		raw = jsonDeadline
+	}
+	if raw == "" {
+		return defaultRequestTimeout
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return defaultRequestTimeout
+	}
+	if d > maxRequestTimeout {
+		return maxRequestTimeout
+	}
+	return d
+}
+
+// pendingOperations tracks the in-flight operation (if any) for a given
+// channel, so DELETE /channels/:name/pending can find and cancel it.
+type pendingOperations struct {
+	mu    sync.Mutex
+	byKey map[string]*Operation
+}
+
+var channelPending = &pendingOperations{byKey: make(map[string]*Operation)}
+
+func (p *pendingOperations) set(channelName string, op *Operation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.byKey[channelName] = op
+}
+
+func (p *pendingOperations) clear(channelName string, op *Operation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.byKey[channelName] == op {
+		delete(p.byKey, channelName)
+	}
+}
+
+func (p *pendingOperations) get(channelName string) (*Operation, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	op, ok := p.byKey[channelName]
+	return op, ok
+}
+
+// runWithDeadline runs fn bounded by ctx, registers the resulting
+// operation as the channel's pending operation so it can be cancelled via
+// DELETE /channels/:name/pending, and rolls the channel name back out of
+// `workers` if ctx is cancelled before fn finishes, so it becomes reusable
+// instead of leaving a zombie entry behind.
+func runWithDeadline(ctx context.Context, channelName string, op *Operation, fn func() error) {
+	channelPending.set(channelName, op)
+	defer channelPending.clear(channelName, op)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			op.finish(OperationFailure, err)
+			return
+		}
+		op.finish(OperationSuccess, nil)
+
+	case <-ctx.Done():
+		op.finish(OperationCancelled, errRequestCancelled(ctx.Err()))
+		// fn is expected to call a ctx-aware worker method (startCtx/
+		// stopCtx/updateCtx) that observes ctx itself: on cancellation it
+		// signals the spawned process (SIGTERM, then SIGKILL after
+		// killGracePeriod if it hasn't exited) and only returns once that
+		// process is confirmed gone. Waiting for `done` here -- rather
+		// than racing a second, independent timer -- means the channel
+		// name is never rolled back while fn might still be running
+		// against it.
+		<-done
+		workers.Remove(channelName)
+	}
+}
+
+// handlerChannelCancelPending cancels whatever operation is currently
+// mid-flight on a channel (start, stop, or update), giving callers real
+// backpressure instead of waiting out a zombie worker.
+func (s *HttpServer) handlerChannelCancelPending(c *gin.Context) {
+	channelName := c.Param("name")
+
+	op, ok := channelPending.get(channelName)
+	if !ok {
+		s.output(c, codeErrChannelNotExisted, http.StatusNotFound)
+		return
+	}
+
+	if !op.Cancel() {
+		s.output(c, codeErrParamsInvalid, http.StatusConflict)
+		return
+	}
+
+	s.output(c, codeSuccess, snapshotOperation(op))
+}
+
+// contextFromRequest derives a context bounded by the client's requested
+// deadline (or the default). It is deliberately rooted at
+// context.Background(), not c.Request.Context(): handlerStart/handlerStop
+// launch the worker RPC in a detached goroutine and return immediately
+// (the whole point of the async 202 design), and net/http cancels a
+// request's Context() as soon as the handler returns -- so a context
+// derived from it would already be cancelled by the time the goroutine
+// does any real work, regardless of the requested deadline.
+func contextFromRequest(c *gin.Context, jsonDeadline string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), requestDeadline(c, jsonDeadline))
+}
+
+// errRequestCancelled wraps ctx.Err() with a message suitable for the
+// operation's recorded Error field.
+func errRequestCancelled(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("request deadline exceeded: %w", err)
+	}
+	return fmt.Errorf("request cancelled: %w", err)
+}
@@ -0,0 +1,135 @@
+/**
+ *
+ * Agora Real Time Engagement
+ * Created by XinHui Li in 2024.
+ * Copyright (c) 2024 Agora IO. All rights reserved.
+ *
+ */
+package internal
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	workersActiveGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "workers_active",
+		Help: "Number of workers currently serving a channel.",
+	})
+	workersDrainingGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "workers_draining",
+		Help: "Number of workers currently being drained for shutdown.",
+	})
+	graphStopDurationSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "graph_stop_duration_seconds",
+		Help:    "Time taken for a worker's root graph to report OnStopDone during drain.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+func init() {
+	prometheus.MustRegister(workersActiveGauge, workersDrainingGauge, graphStopDurationSeconds)
+}
+
+// WorkerPool owns the lifecycle of every Worker assigned to an incoming
+// HTTP session. It replaces the previous blunt CleanWorkers() kill with a
+// bounded graceful drain: stop accepting new sessions, ask every worker's
+// root graph to stop, wait for OnStopDone up to the configured timeout,
+// then force-kill whatever is left.
+type WorkerPool struct {
+	quitTimeout time.Duration
+
+	draining atomic.Bool
+}
+
+// NewWorkerPool creates a pool bounded by quitTimeout, the maximum time
+// Drain will wait for a worker's graph to stop gracefully before it is
+// force-killed.
+func NewWorkerPool(quitTimeout time.Duration) *WorkerPool {
+	return &WorkerPool{quitTimeout: quitTimeout}
+}
+
+// Accepting reports whether the pool is still accepting new sessions. The
+// HTTP handlers should consult this before calling handlerStart.
+func (p *WorkerPool) Accepting() bool {
+	return !p.draining.Load()
+}
+
+// Ready reports whether the pool is healthy and accepting work, for the
+// /readyz split from the plain /healthz liveness check.
+func (p *WorkerPool) Ready() bool {
+	return p.Accepting()
+}
+
+// UpdateMetrics refreshes the Prometheus gauges from the current worker
+// set. It is cheap enough to call on every /metrics scrape.
+func (p *WorkerPool) UpdateMetrics() {
+	active := 0
+	for range workers.Keys() {
+		active++
+	}
+
+	workersActiveGauge.Set(float64(active))
+	if p.draining.Load() {
+		workersDrainingGauge.Set(float64(active))
+	} else {
+		workersDrainingGauge.Set(0)
+	}
+}
+
+// Drain stops accepting new sessions, asks every worker to stop its root
+// graph, and waits up to the pool's quitTimeout for each to finish before
+// force-killing stragglers. It returns once every worker has been
+// accounted for.
+func (p *WorkerPool) Drain(ctx context.Context) {
+	p.draining.Store(true)
+	defer p.draining.Store(false)
+
+	channelNames := workers.Keys()
+	slog.Info("worker pool drain start", "workers", len(channelNames), logTag)
+
+	var wg sync.WaitGroup
+	for _, channelName := range channelNames {
+		channelName := channelName
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.drainOne(ctx, channelName)
+		}()
+	}
+	wg.Wait()
+
+	slog.Info("worker pool drain complete", logTag)
+}
+
+func (p *WorkerPool) drainOne(ctx context.Context, channelName string) {
+	w, ok := workers.Get(channelName).(*Worker)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+
+	// drainCtx bounds how long stopCtx waits for a graceful OnStopDone
+	// before it escalates to SIGTERM/SIGKILL on the worker's process
+	// internally (see killGracePeriod); stopCtx only returns once the
+	// process is actually gone, so there is no separate "timed out,
+	// force removing" branch here that would race ahead of a still-running
+	// worker the way the old plain w.stop(...) call ignorant of drainCtx
+	// did.
+	drainCtx, cancel := context.WithTimeout(ctx, p.quitTimeout)
+	defer cancel()
+
+	if err := w.stopCtx(drainCtx, "worker-pool-drain", channelName); err != nil {
+		slog.Error("worker drain stop failed", "channelName", channelName, "err", err, logTag)
+	}
+
+	workers.Remove(channelName)
+	graphStopDurationSeconds.Observe(time.Since(start).Seconds())
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log/slog"
@@ -8,6 +9,7 @@ import (
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
@@ -73,17 +75,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Set up signal handler to clean up all workers on Ctrl+C
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-
-	go func() {
-		<-sigs
-		fmt.Println("Received interrupt signal, cleaning up workers...")
-		internal.CleanWorkers()
-		os.Exit(0)
-	}()
-
 	// Start server
 	httpServerConfig := &internal.HttpServerConfig{
 		AppId:                    agoraAppId,
@@ -99,5 +90,24 @@ func main() {
 	slog.Info("Server configured with tenapp_dir", "tenappDir", tenappDir)
 
 	httpServer := internal.NewHttpServer(httpServerConfig)
+
+	// Set up signal handler to perform a bounded graceful drain on Ctrl+C:
+	// stop accepting new sessions, ask every worker's root graph to stop,
+	// and give it up to WorkerQuitTimeoutSeconds before force-killing
+	// whatever is left.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		<-sigs
+		fmt.Println("Received interrupt signal, draining workers...")
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(workerQuitTimeoutSeconds)*time.Second)
+		defer cancel()
+
+		httpServer.Drain(ctx)
+		os.Exit(0)
+	}()
+
 	httpServer.Start()
 }
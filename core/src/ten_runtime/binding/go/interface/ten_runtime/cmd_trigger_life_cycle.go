@@ -41,6 +41,13 @@ func NewTriggerLifeCycleCmd() (TriggerLifeCycleCmd, error) {
 
 type triggerLifeCycleCmd struct {
 	*cmd
+
+	// stage is the last stage successfully set on this command, used as
+	// the "from" side of validateLifeCycleTransition for the next
+	// SetStage call. The zero value means this command has not had a
+	// stage set yet; the command does not know its destination's current
+	// stage, so no transition check is made in that case.
+	stage LifeCycleStage
 }
 
 func newTriggerLifeCycleCmd(bridge C.uintptr_t) *triggerLifeCycleCmd {
@@ -49,10 +56,26 @@ func newTriggerLifeCycleCmd(bridge C.uintptr_t) *triggerLifeCycleCmd {
 	}
 }
 
+// SetStage sets the manual life cycle stage the command will trigger on
+// its destination extension. Accepted stages are "init", "start", "pause",
+// "resume", "stop", and "deinit"; any other value returns
+// *ErrInvalidLifeCycleStage instead of being sent to the runtime. If a
+// stage was already set on this command, the new stage must also be
+// legally reachable from it, or *ErrInvalidLifeCycleStage is returned.
 func (p *triggerLifeCycleCmd) SetStage(stage string) error {
 	defer p.keepAlive()
 
-	err := withCGOLimiter(func() error {
+	s, err := validateLifeCycleStage(stage)
+	if err != nil {
+		return err
+	}
+	if p.stage != "" {
+		if err := validateLifeCycleTransition(p.stage, s); err != nil {
+			return err
+		}
+	}
+
+	err = withCGOLimiter(func() error {
 		apiStatus := C.ten_go_cmd_trigger_life_cycle_set_stage(
 			p.getCPtr(),
 			unsafe.Pointer(unsafe.StringData(stage)),
@@ -60,8 +83,12 @@ func (p *triggerLifeCycleCmd) SetStage(stage string) error {
 		)
 		return withCGoError(&apiStatus)
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	p.stage = s
+	return nil
 }
 
 var _ TriggerLifeCycleCmd = new(triggerLifeCycleCmd)
@@ -0,0 +1,177 @@
+//
+// Copyright © 2025 Agora
+// This file is part of TEN Framework, an open source project.
+// Licensed under the Apache License, Version 2.0, with certain conditions.
+// Refer to the "LICENSE" file in the root directory for more information.
+//
+
+package ten_runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// GraphAdmissionCmdKind identifies which command triggered an admission
+// hook.
+type GraphAdmissionCmdKind string
+
+const (
+	GraphAdmissionStartGraph GraphAdmissionCmdKind = "start_graph"
+	GraphAdmissionStopGraph  GraphAdmissionCmdKind = "stop_graph"
+)
+
+// GraphAdmissionRequest carries everything a hook needs to allow, deny, or
+// mutate a start_graph/stop_graph command: the parsed graph JSON (nil for
+// stop_graph), the identity of the extension that issued the command, and
+// the command kind.
+type GraphAdmissionRequest struct {
+	Kind          GraphAdmissionCmdKind
+	SourceApp     string
+	SourceGraphID string
+	SourceExt     string
+	Graph         map[string]interface{}
+}
+
+// GraphAdmissionResponse is the result of running a request through one
+// hook. A denied request short-circuits the remaining chain; Graph, if
+// non-nil, replaces the request's Graph before the next hook runs.
+type GraphAdmissionResponse struct {
+	Allowed bool
+	Reason  string
+	Graph   map[string]interface{}
+}
+
+// GraphAdmissionHook inspects (and optionally mutates) a graph admission
+// request. Hooks are expected to be pure with respect to their inputs so
+// they can be unit tested without a running app.
+type GraphAdmissionHook func(ctx context.Context, req GraphAdmissionRequest) (GraphAdmissionResponse, error)
+
+var (
+	admissionHooksMu sync.Mutex
+	admissionHooks   []GraphAdmissionHook
+)
+
+// RegisterGraphAdmissionHook appends hook to the chain run for every
+// start_graph/stop_graph command. Hooks registered earlier run first, and
+// registration is expected to happen once at addon init time.
+func RegisterGraphAdmissionHook(hook GraphAdmissionHook) {
+	admissionHooksMu.Lock()
+	defer admissionHooksMu.Unlock()
+
+	admissionHooks = append(admissionHooks, hook)
+}
+
+// RunGraphAdmissionChain runs req through every registered hook in order.
+// The first denial wins; otherwise the final response carries whichever
+// mutated Graph the last hook that touched it returned.
+func RunGraphAdmissionChain(ctx context.Context, req GraphAdmissionRequest) (GraphAdmissionResponse, error) {
+	admissionHooksMu.Lock()
+	hooks := make([]GraphAdmissionHook, len(admissionHooks))
+	copy(hooks, admissionHooks)
+	admissionHooksMu.Unlock()
+
+	resp := GraphAdmissionResponse{Allowed: true, Graph: req.Graph}
+
+	for i, hook := range hooks {
+		req.Graph = resp.Graph
+
+		hookResp, err := hook(ctx, req)
+		if err != nil {
+			return GraphAdmissionResponse{}, fmt.Errorf("ten: admission hook #%d failed: %w", i, err)
+		}
+
+		if !hookResp.Allowed {
+			if hookResp.Reason == "" {
+				hookResp.Reason = fmt.Sprintf("denied by admission hook #%d", i)
+			}
+			return hookResp, nil
+		}
+
+		if hookResp.Graph != nil {
+			resp.Graph = hookResp.Graph
+		}
+	}
+
+	return resp, nil
+}
+
+// NewStartGraphCmdWithAdmission runs a start_graph request built from raw
+// graph JSON through RunGraphAdmissionChain before building the
+// StartGraphCmd, so extensions that assemble their graph JSON by hand (as
+// opposed to loading a GraphSpec file via NewStartGraphCmdFromSpec) still
+// go through policy. sourceExt identifies the extension issuing the
+// command.
+//
+// Plain ten.NewStartGraphCmd()/ten.NewStopGraphCmd() remain available and
+// do not run the admission chain -- they predate it and changing their
+// signature would break every existing caller. New start_graph/stop_graph
+// call sites should go through this constructor (or
+// NewStopGraphCmdWithAdmission, or NewStartGraphCmdFromSpec/FromFile)
+// instead of the raw constructors whenever admission hooks matter.
+func NewStartGraphCmdWithAdmission(ctx context.Context, graphJSON []byte, sourceExt string) (StartGraphCmd, error) {
+	var graph map[string]interface{}
+	if err := json.Unmarshal(graphJSON, &graph); err != nil {
+		return nil, fmt.Errorf("ten: parse graph JSON: %w", err)
+	}
+
+	resp, err := RunGraphAdmissionChain(ctx, GraphAdmissionRequest{
+		Kind:      GraphAdmissionStartGraph,
+		SourceExt: sourceExt,
+		Graph:     graph,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ten: start_graph admission: %w", err)
+	}
+	if !resp.Allowed {
+		return nil, fmt.Errorf("ten: start_graph denied: %s", resp.Reason)
+	}
+	if resp.Graph != nil {
+		graph = resp.Graph
+	}
+
+	cmd, err := NewStartGraphCmd()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(graph)
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.SetGraphFromJSONBytes(b); err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+// NewStopGraphCmdWithAdmission runs a stop_graph request for graphID through
+// RunGraphAdmissionChain before building the StopGraphCmd, so a registered
+// admission hook can veto tearing a graph down the same way it can veto
+// starting one. sourceExt identifies the extension issuing the stop.
+func NewStopGraphCmdWithAdmission(ctx context.Context, graphID, sourceExt string) (StopGraphCmd, error) {
+	resp, err := RunGraphAdmissionChain(ctx, GraphAdmissionRequest{
+		Kind:          GraphAdmissionStopGraph,
+		SourceGraphID: graphID,
+		SourceExt:     sourceExt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ten: stop_graph admission: %w", err)
+	}
+	if !resp.Allowed {
+		return nil, fmt.Errorf("ten: stop_graph denied: %s", resp.Reason)
+	}
+
+	cmd, err := NewStopGraphCmd()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.SetGraphID(graphID); err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
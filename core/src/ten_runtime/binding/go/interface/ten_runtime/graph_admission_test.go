@@ -0,0 +1,141 @@
+//
+// Copyright © 2025 Agora
+// This file is part of TEN Framework, an open source project.
+// Licensed under the Apache License, Version 2.0, with certain conditions.
+// Refer to the "LICENSE" file in the root directory for more information.
+//
+
+package ten_runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// resetAdmissionHooks clears the package-level hook chain and restores it
+// after the test, so tests can register their own hooks without leaking
+// state into other tests.
+func resetAdmissionHooks(t *testing.T) {
+	t.Helper()
+	admissionHooksMu.Lock()
+	saved := admissionHooks
+	admissionHooks = nil
+	admissionHooksMu.Unlock()
+
+	t.Cleanup(func() {
+		admissionHooksMu.Lock()
+		admissionHooks = saved
+		admissionHooksMu.Unlock()
+	})
+}
+
+func TestRunGraphAdmissionChain_NoHooksAllows(t *testing.T) {
+	resetAdmissionHooks(t)
+
+	graph := map[string]interface{}{"nodes": []interface{}{}}
+	resp, err := RunGraphAdmissionChain(context.Background(), GraphAdmissionRequest{
+		Kind:  GraphAdmissionStartGraph,
+		Graph: graph,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Allowed {
+		t.Fatalf("expected allowed response with no hooks registered")
+	}
+}
+
+func TestRunGraphAdmissionChain_DenialShortCircuits(t *testing.T) {
+	resetAdmissionHooks(t)
+
+	var secondHookCalled bool
+	RegisterGraphAdmissionHook(func(ctx context.Context, req GraphAdmissionRequest) (GraphAdmissionResponse, error) {
+		return GraphAdmissionResponse{Allowed: false, Reason: "not allowed"}, nil
+	})
+	RegisterGraphAdmissionHook(func(ctx context.Context, req GraphAdmissionRequest) (GraphAdmissionResponse, error) {
+		secondHookCalled = true
+		return GraphAdmissionResponse{Allowed: true}, nil
+	})
+
+	resp, err := RunGraphAdmissionChain(context.Background(), GraphAdmissionRequest{Kind: GraphAdmissionStartGraph})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Allowed {
+		t.Fatalf("expected denial from first hook")
+	}
+	if resp.Reason != "not allowed" {
+		t.Fatalf("reason = %q, want %q", resp.Reason, "not allowed")
+	}
+	if secondHookCalled {
+		t.Fatalf("second hook should not run after a denial")
+	}
+}
+
+func TestRunGraphAdmissionChain_DefaultReasonWhenUnset(t *testing.T) {
+	resetAdmissionHooks(t)
+
+	RegisterGraphAdmissionHook(func(ctx context.Context, req GraphAdmissionRequest) (GraphAdmissionResponse, error) {
+		return GraphAdmissionResponse{Allowed: false}, nil
+	})
+
+	resp, err := RunGraphAdmissionChain(context.Background(), GraphAdmissionRequest{Kind: GraphAdmissionStartGraph})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Allowed {
+		t.Fatalf("expected denial")
+	}
+	if resp.Reason == "" {
+		t.Fatalf("expected a default reason to be filled in")
+	}
+}
+
+func TestRunGraphAdmissionChain_MutationCarriesForward(t *testing.T) {
+	resetAdmissionHooks(t)
+
+	RegisterGraphAdmissionHook(func(ctx context.Context, req GraphAdmissionRequest) (GraphAdmissionResponse, error) {
+		mutated := map[string]interface{}{"nodes": []interface{}{"added-by-hook-1"}}
+		return GraphAdmissionResponse{Allowed: true, Graph: mutated}, nil
+	})
+	RegisterGraphAdmissionHook(func(ctx context.Context, req GraphAdmissionRequest) (GraphAdmissionResponse, error) {
+		nodes, _ := req.Graph["nodes"].([]interface{})
+		if len(nodes) != 1 || nodes[0] != "added-by-hook-1" {
+			t.Fatalf("second hook did not see first hook's mutation: %v", req.Graph)
+		}
+		return GraphAdmissionResponse{Allowed: true}, nil
+	})
+
+	resp, err := RunGraphAdmissionChain(context.Background(), GraphAdmissionRequest{
+		Kind:  GraphAdmissionStartGraph,
+		Graph: map[string]interface{}{"nodes": []interface{}{}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Allowed {
+		t.Fatalf("expected allowed response")
+	}
+	nodes, _ := resp.Graph["nodes"].([]interface{})
+	if len(nodes) != 1 {
+		t.Fatalf("expected first hook's mutation to survive in the final response, got %v", resp.Graph)
+	}
+}
+
+func TestRunGraphAdmissionChain_HookErrorAborts(t *testing.T) {
+	resetAdmissionHooks(t)
+
+	wantErr := errors.New("boom")
+	RegisterGraphAdmissionHook(func(ctx context.Context, req GraphAdmissionRequest) (GraphAdmissionResponse, error) {
+		return GraphAdmissionResponse{}, wantErr
+	})
+
+	_, err := RunGraphAdmissionChain(context.Background(), GraphAdmissionRequest{Kind: GraphAdmissionStopGraph})
+	if err == nil {
+		t.Fatalf("expected an error when a hook fails")
+	}
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("err = %v, want wrapped %v", err, wantErr)
+	}
+}
@@ -0,0 +1,300 @@
+//
+// Copyright © 2025 Agora
+// This file is part of TEN Framework, an open source project.
+// Licensed under the Apache License, Version 2.0, with certain conditions.
+// Refer to the "LICENSE" file in the root directory for more information.
+//
+
+package ten_runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// GraphSpec is a loaded, include-resolved graph definition: the same
+// "nodes"/"connections"/"exposed_messages" document StartGraphCmd already
+// accepts, but sourced from a YAML or JSON file instead of being embedded
+// as a Go string literal, and with $include/$ref fragments expanded.
+type GraphSpec struct {
+	raw map[string]interface{}
+}
+
+// graphSpecSchema is the JSON Schema new graph documents are validated
+// against. It only constrains the shape StartGraphCmd.SetGraphFromJSONBytes
+// actually reads: nodes, connections, and exposed_messages. A connection's
+// "cmd" and "data" entries are only required to be arrays; their contents,
+// including any msg_conversion rules, are not checked.
+const graphSpecSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["nodes"],
+  "properties": {
+    "nodes": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["type", "name", "addon"],
+        "properties": {
+          "type": {"type": "string"},
+          "name": {"type": "string"},
+          "addon": {"type": "string"}
+        }
+      }
+    },
+    "connections": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["extension"],
+        "properties": {
+          "extension": {"type": "string"},
+          "cmd": {"type": "array"},
+          "data": {"type": "array"}
+        }
+      }
+    },
+    "exposed_messages": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["type", "name", "extension"],
+        "properties": {
+          "type": {"type": "string"},
+          "name": {"type": "string"},
+          "extension": {"type": "string"}
+        }
+      }
+    }
+  }
+}`
+
+// LoadGraphSpecFromFile reads a graph definition from path (YAML or JSON,
+// selected by extension), resolves any $include/$ref fragments relative to
+// the file's directory, and validates the result against the graph schema.
+func LoadGraphSpecFromFile(path string) (*GraphSpec, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ten: read graph spec %q: %w", path, err)
+	}
+
+	raw, err := decodeGraphDoc(path, content)
+	if err != nil {
+		return nil, fmt.Errorf("ten: parse graph spec %q: %w", path, err)
+	}
+
+	resolved, err := resolveGraphIncludes(raw, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("ten: resolve includes in %q: %w", path, err)
+	}
+
+	if err := ValidateGraphSpec(resolved); err != nil {
+		return nil, fmt.Errorf("ten: invalid graph spec %q: %w", path, err)
+	}
+
+	return &GraphSpec{raw: resolved}, nil
+}
+
+// ValidateGraphSpec validates a decoded graph document against the graph
+// schema, returning a single error aggregating every violation found.
+func ValidateGraphSpec(doc map[string]interface{}) error {
+	schemaLoader := gojsonschema.NewStringLoader(graphSpecSchema)
+	docLoader := gojsonschema.NewGoLoader(doc)
+
+	result, err := gojsonschema.Validate(schemaLoader, docLoader)
+	if err != nil {
+		return fmt.Errorf("ten: run schema validation: %w", err)
+	}
+
+	if !result.Valid() {
+		msg := "graph spec failed schema validation:"
+		for _, e := range result.Errors() {
+			msg += "\n  - " + e.String()
+		}
+		return fmt.Errorf(msg)
+	}
+
+	return nil
+}
+
+// Bytes marshals the resolved graph spec back to the JSON form
+// StartGraphCmd.SetGraphFromJSONBytes expects.
+func (s *GraphSpec) Bytes() ([]byte, error) {
+	return json.Marshal(s.raw)
+}
+
+// NewStartGraphCmdFromFile loads, resolves, and validates the graph spec at
+// path, then builds a StartGraphCmd from it. Unlike embedding the JSON as a
+// Go string literal, a malformed file is reported as an error here rather
+// than a runtime panic from SetGraphFromJSONBytes.
+func NewStartGraphCmdFromFile(path string) (StartGraphCmd, error) {
+	spec, err := LoadGraphSpecFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewStartGraphCmdFromSpec(spec)
+}
+
+// NewStartGraphCmdFromSpec builds a StartGraphCmd from an already-loaded
+// GraphSpec, after running it through RunGraphAdmissionChain so any hook
+// registered via RegisterGraphAdmissionHook gets a chance to deny or
+// rewrite it before it is ever sent to the runtime.
+func NewStartGraphCmdFromSpec(spec *GraphSpec) (StartGraphCmd, error) {
+	graph := spec.raw
+
+	resp, err := RunGraphAdmissionChain(context.Background(), GraphAdmissionRequest{
+		Kind:  GraphAdmissionStartGraph,
+		Graph: graph,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ten: start_graph admission: %w", err)
+	}
+	if !resp.Allowed {
+		return nil, fmt.Errorf("ten: start_graph denied: %s", resp.Reason)
+	}
+	if resp.Graph != nil {
+		graph = resp.Graph
+	}
+
+	cmd, err := NewStartGraphCmd()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(graph)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.SetGraphFromJSONBytes(b); err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+func decodeGraphDoc(path string, content []byte) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, err
+		}
+	default:
+		if err := json.Unmarshal(content, &doc); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// resolveGraphIncludes walks doc looking for "$include"/"$ref" fragments of
+// the form {"$include": "relative/path.yaml"} or {"$ref": "relative/path.yaml"},
+// loads the referenced file relative to baseDir, and splices the whole file
+// in place. The value is used as a file path only: a "#/pointer" suffix is
+// not interpreted and is read as part of the file name.
+// It recurses into maps and slices so fragments can appear anywhere in the
+// document (a single node, a whole "connections" array, etc).
+func resolveGraphIncludes(node interface{}, baseDir string) (map[string]interface{}, error) {
+	resolved, err := resolveGraphNode(node, baseDir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := resolved.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("resolved document is not an object")
+	}
+	return m, nil
+}
+
+// maxIncludeDepth guards against cyclic $include chains.
+const maxIncludeDepth = 16
+
+func resolveGraphNode(node interface{}, baseDir string, depth int) (interface{}, error) {
+	if depth > maxIncludeDepth {
+		return nil, fmt.Errorf("$include/$ref nesting exceeds %d levels", maxIncludeDepth)
+	}
+
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := includeTarget(v); ok {
+			included, err := loadIncludeFragment(ref, baseDir)
+			if err != nil {
+				return nil, err
+			}
+			return resolveGraphNode(included, filepath.Dir(filepath.Join(baseDir, ref)), depth+1)
+		}
+
+		out := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			resolvedVal, err := resolveGraphNode(val, baseDir, depth)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolvedVal
+		}
+		return out, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			resolvedVal, err := resolveGraphNode(val, baseDir, depth)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolvedVal
+		}
+		return out, nil
+
+	default:
+		return v, nil
+	}
+}
+
+func includeTarget(m map[string]interface{}) (string, bool) {
+	if v, ok := m["$include"]; ok {
+		if s, ok := v.(string); ok {
+			return s, true
+		}
+	}
+	if v, ok := m["$ref"]; ok {
+		if s, ok := v.(string); ok {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+func loadIncludeFragment(ref, baseDir string) (interface{}, error) {
+	path := filepath.Join(baseDir, ref)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read included fragment %q: %w", path, err)
+	}
+
+	var fragment interface{}
+	if err := decodeFragment(path, content, &fragment); err != nil {
+		return nil, fmt.Errorf("parse included fragment %q: %w", path, err)
+	}
+
+	return fragment, nil
+}
+
+func decodeFragment(path string, content []byte, out *interface{}) error {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return yaml.Unmarshal(content, out)
+	default:
+		return json.Unmarshal(content, out)
+	}
+}
@@ -0,0 +1,145 @@
+//
+// Copyright © 2025 Agora
+// This file is part of TEN Framework, an open source project.
+// Licensed under the Apache License, Version 2.0, with certain conditions.
+// Refer to the "LICENSE" file in the root directory for more information.
+//
+
+package ten_runtime
+
+import "fmt"
+
+// LifeCycleStage enumerates the stages a TriggerLifeCycleCmd can carry.
+// Beyond the original "start"/"stop", extensions can be driven through
+// "init"/"deinit" and temporarily suspended with "pause"/"resume" without
+// tearing the extension down.
+type LifeCycleStage string
+
+const (
+	LifeCycleStageInit   LifeCycleStage = "init"
+	LifeCycleStageStart  LifeCycleStage = "start"
+	LifeCycleStagePause  LifeCycleStage = "pause"
+	LifeCycleStageResume LifeCycleStage = "resume"
+	LifeCycleStageStop   LifeCycleStage = "stop"
+	LifeCycleStageDeinit LifeCycleStage = "deinit"
+)
+
+var validLifeCycleStages = map[LifeCycleStage]struct{}{
+	LifeCycleStageInit:   {},
+	LifeCycleStageStart:  {},
+	LifeCycleStagePause:  {},
+	LifeCycleStageResume: {},
+	LifeCycleStageStop:   {},
+	LifeCycleStageDeinit: {},
+}
+
+// lifeCycleTransitions lists the stage a peer extension must already be in
+// before it can legally be moved to a given stage. An empty predecessor set
+// means the stage is reachable from the initial (not-yet-inited) state.
+var lifeCycleTransitions = map[LifeCycleStage][]LifeCycleStage{
+	LifeCycleStageInit:   {},
+	LifeCycleStageStart:  {LifeCycleStageInit, LifeCycleStageResume},
+	LifeCycleStagePause:  {LifeCycleStageStart},
+	LifeCycleStageResume: {LifeCycleStagePause},
+	LifeCycleStageStop:   {LifeCycleStageStart, LifeCycleStagePause},
+	LifeCycleStageDeinit: {LifeCycleStageInit, LifeCycleStageStop},
+}
+
+// ErrInvalidLifeCycleStage is returned by SetStage (or by the runtime, when
+// a peer extension's current stage does not permit the requested
+// transition) instead of panicking, so callers can surface a CmdResult
+// error rather than crashing the process.
+type ErrInvalidLifeCycleStage struct {
+	Stage LifeCycleStage
+	From  LifeCycleStage
+	Known bool
+}
+
+func (e *ErrInvalidLifeCycleStage) Error() string {
+	if !e.Known {
+		return fmt.Sprintf("ten: unknown life cycle stage %q", string(e.Stage))
+	}
+	return fmt.Sprintf("ten: illegal life cycle transition from %q to %q", string(e.From), string(e.Stage))
+}
+
+// validateLifeCycleStage reports whether stage is a recognized stage name.
+func validateLifeCycleStage(stage string) (LifeCycleStage, error) {
+	s := LifeCycleStage(stage)
+	if _, ok := validLifeCycleStages[s]; !ok {
+		return "", &ErrInvalidLifeCycleStage{Stage: s, Known: false}
+	}
+	return s, nil
+}
+
+// validateLifeCycleTransition reports whether moving a peer currently in
+// `from` to `to` is legal. A zero-value `from` means the peer has not been
+// through any manual stage yet.
+func validateLifeCycleTransition(from, to LifeCycleStage) error {
+	allowedFrom, ok := lifeCycleTransitions[to]
+	if !ok {
+		return &ErrInvalidLifeCycleStage{Stage: to, Known: false}
+	}
+	if len(allowedFrom) == 0 {
+		return nil
+	}
+	for _, f := range allowedFrom {
+		if f == from {
+			return nil
+		}
+	}
+	return &ErrInvalidLifeCycleStage{Stage: to, From: from, Known: true}
+}
+
+// The following default (no-op) callbacks round out DefaultExtension for
+// the stages introduced alongside LifeCycleStage. Extensions that want
+// fine-grained manual lifecycle control (e.g. the mainExtension/bizExtension
+// pattern in default_extension_go) override the ones they care about.
+//
+// OnManualInit/OnManualDeinit are deliberately named (instead of reusing
+// OnInit/OnDeinit) since every extension already has an OnInit/OnDeinit
+// pair the runtime invokes automatically for graph startup/teardown, and a
+// TriggerLifeCycleCmd with stage "init"/"deinit" is a distinct,
+// peer-triggered stage transition that must not be conflated with that
+// automatic graph lifecycle -- but completion is still signaled through
+// the same OnInitDone/OnDeinitDone that the automatic path uses, since
+// from the runtime's perspective it is the same stage finishing, just
+// triggered a different way (mirroring how stage "start"/"stop" already
+// reuse OnStart/OnStartDone and OnStop/OnStopDone for both the automatic
+// and peer-triggered paths).
+//
+// OnPause/OnResume have no automatic counterpart and are expected to
+// complete synchronously: unlike init/start/stop/deinit, which guard
+// real setup/teardown work and so need an explicit "done" signal, a
+// pause/resume transition is complete as soon as the callback returns.
+// Extensions that need to do asynchronous work before actually pausing or
+// resuming should track that internally and reply to the peer through
+// their own command/data channel rather than the trigger-lifecycle cmd.
+
+// OnManualInit is invoked when this extension receives a
+// TriggerLifeCycleCmd with stage "init", letting a peer extension move it
+// out of its initial state without waiting on graph startup. The default
+// implementation immediately acknowledges.
+func (p *DefaultExtension) OnManualInit(tenEnv TenEnv) {
+	tenEnv.OnInitDone()
+}
+
+// OnPause is invoked when this extension receives a TriggerLifeCycleCmd
+// with stage "pause". The default implementation is a no-op; returning
+// from this method is itself the completion signal.
+func (p *DefaultExtension) OnPause(tenEnv TenEnv) {
+}
+
+// OnResume is invoked when this extension receives a TriggerLifeCycleCmd
+// with stage "resume". The default implementation is a no-op; returning
+// from this method is itself the completion signal.
+func (p *DefaultExtension) OnResume(tenEnv TenEnv) {
+}
+
+// OnManualDeinit is invoked when this extension receives a
+// TriggerLifeCycleCmd with stage "deinit", allowing a peer extension to
+// release resources acquired during a manual "init" without a full
+// OnStop/OnDeinit teardown triggered by graph shutdown. The default
+// implementation immediately acknowledges.
+func (p *DefaultExtension) OnManualDeinit(tenEnv TenEnv) {
+	tenEnv.OnDeinitDone()
+}
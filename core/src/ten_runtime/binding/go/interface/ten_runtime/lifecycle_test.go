@@ -0,0 +1,63 @@
+//
+// Copyright © 2025 Agora
+// This file is part of TEN Framework, an open source project.
+// Licensed under the Apache License, Version 2.0, with certain conditions.
+// Refer to the "LICENSE" file in the root directory for more information.
+//
+
+package ten_runtime
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateLifeCycleStage_Unknown(t *testing.T) {
+	_, err := validateLifeCycleStage("bogus")
+	if err == nil {
+		t.Fatalf("expected an error for an unknown stage")
+	}
+	var stageErr *ErrInvalidLifeCycleStage
+	if !errors.As(err, &stageErr) {
+		t.Fatalf("err = %v, want *ErrInvalidLifeCycleStage", err)
+	}
+	if stageErr.Known {
+		t.Fatalf("expected Known = false for an unrecognized stage")
+	}
+}
+
+func TestValidateLifeCycleTransition_InitFromInitialAllowed(t *testing.T) {
+	if err := validateLifeCycleTransition("", LifeCycleStageInit); err != nil {
+		t.Fatalf("unexpected error moving to init from the initial state: %v", err)
+	}
+}
+
+func TestValidateLifeCycleTransition_PauseRequiresStart(t *testing.T) {
+	if err := validateLifeCycleTransition("", LifeCycleStagePause); err == nil {
+		t.Fatalf("expected an error pausing from the initial state")
+	}
+	if err := validateLifeCycleTransition(LifeCycleStageStart, LifeCycleStagePause); err != nil {
+		t.Fatalf("unexpected error pausing from start: %v", err)
+	}
+}
+
+func TestValidateLifeCycleTransition_ResumeRequiresPause(t *testing.T) {
+	if err := validateLifeCycleTransition(LifeCycleStageStart, LifeCycleStageResume); err == nil {
+		t.Fatalf("expected an error resuming directly from start")
+	}
+	if err := validateLifeCycleTransition(LifeCycleStagePause, LifeCycleStageResume); err != nil {
+		t.Fatalf("unexpected error resuming from pause: %v", err)
+	}
+}
+
+func TestValidateLifeCycleTransition_StopAllowedFromStartOrPause(t *testing.T) {
+	if err := validateLifeCycleTransition(LifeCycleStageStart, LifeCycleStageStop); err != nil {
+		t.Fatalf("unexpected error stopping from start: %v", err)
+	}
+	if err := validateLifeCycleTransition(LifeCycleStagePause, LifeCycleStageStop); err != nil {
+		t.Fatalf("unexpected error stopping from pause: %v", err)
+	}
+	if err := validateLifeCycleTransition(LifeCycleStageInit, LifeCycleStageStop); err == nil {
+		t.Fatalf("expected an error stopping directly from init")
+	}
+}
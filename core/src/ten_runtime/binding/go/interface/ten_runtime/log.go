@@ -0,0 +1,246 @@
+//
+// Copyright © 2025 Agora
+// This file is part of TEN Framework, an open source project.
+// Licensed under the Apache License, Version 2.0, with certain conditions.
+// Refer to the "LICENSE" file in the root directory for more information.
+//
+
+package ten_runtime
+
+// #include "log.h"
+import "C"
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"unsafe"
+)
+
+// LogLevel mirrors the levels understood by the underlying C log sink.
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// Logger is the structured logging surface exposed to extensions via
+// TenEnv.Logger(). Unlike the free-form LogInfo/LogError helpers, callers
+// attach key/value pairs that are forwarded to the underlying C log sink
+// as structured fields rather than being string-formatted by hand.
+type Logger interface {
+	Debug(msg string, kv ...any)
+	Info(msg string, kv ...any)
+	Warn(msg string, kv ...any)
+	Error(msg string, kv ...any)
+
+	// With returns a Logger that prepends kv to every subsequent record.
+	With(kv ...any) Logger
+
+	// WithGroup returns a Logger that prefixes the key of every pair in
+	// each record with name and a dot. Unlike slog.Logger.WithGroup, the
+	// prefix also applies to pairs added by earlier With calls and to the
+	// automatic graph_id/extension_name/cmd_id pairs.
+	WithGroup(name string) Logger
+}
+
+// logger is the default Logger implementation. It keeps a bridge handle to
+// the owning ten_env so records can be enriched with graph_id, extension
+// name, and (if present) cmd_id before being handed to the C log sink.
+type logger struct {
+	tenEnvBridge C.uintptr_t
+	attrs        []any
+	groups       []string
+
+	graphID       string
+	extensionName string
+	cmdID         string
+}
+
+func newLogger(tenEnvBridge C.uintptr_t, graphID, extensionName string) *logger {
+	return &logger{
+		tenEnvBridge:  tenEnvBridge,
+		graphID:       graphID,
+		extensionName: extensionName,
+	}
+}
+
+// withCmdScope returns a Logger enriched with the id of cmd, so every
+// record emitted while handling it carries cmd_id automatically.
+func (l *logger) withCmdScope(cmd Cmd) Logger {
+	next := *l
+	if cmd != nil {
+		if id, err := cmd.GetPropertyString("_ten_cmd_id"); err == nil {
+			next.cmdID = id
+		}
+	}
+	return &next
+}
+
+func (l *logger) With(kv ...any) Logger {
+	next := *l
+	next.attrs = append(append([]any{}, l.attrs...), kv...)
+	return &next
+}
+
+func (l *logger) WithGroup(name string) Logger {
+	next := *l
+	next.groups = append(append([]string{}, l.groups...), name)
+	return &next
+}
+
+// enrichment returns the automatic key/value pairs (graph_id,
+// extension_name, cmd_id) that get appended to every record.
+func (l *logger) enrichment() []any {
+	var kv []any
+	if l.graphID != "" {
+		kv = append(kv, "graph_id", l.graphID)
+	}
+	if l.extensionName != "" {
+		kv = append(kv, "extension_name", l.extensionName)
+	}
+	if l.cmdID != "" {
+		kv = append(kv, "cmd_id", l.cmdID)
+	}
+	return kv
+}
+
+func (l *logger) Debug(msg string, kv ...any) { l.log(LogLevelDebug, msg, kv...) }
+func (l *logger) Info(msg string, kv ...any)  { l.log(LogLevelInfo, msg, kv...) }
+func (l *logger) Warn(msg string, kv ...any)  { l.log(LogLevelWarn, msg, kv...) }
+func (l *logger) Error(msg string, kv ...any) { l.log(LogLevelError, msg, kv...) }
+
+func (l *logger) log(level LogLevel, msg string, kv ...any) {
+	all := make([]any, 0, len(l.attrs)+len(kv)+3)
+	all = append(all, l.enrichment()...)
+	all = append(all, l.attrs...)
+	all = append(all, kv...)
+
+	record := slog.NewRecord(time.Now(), slogLevel(level), msg, 0)
+	record.Add(all...)
+
+	line := formatLogRecord(record, l.groups)
+	l.emit(level, line)
+}
+
+func (l *logger) emit(level LogLevel, line string) {
+	cLine := unsafe.Pointer(unsafe.StringData(line))
+
+	_ = withCGOLimiter(func() error {
+		apiStatus := C.ten_go_ten_env_log_with_level(
+			l.tenEnvBridge,
+			C.int(level),
+			cLine,
+			C.int(len(line)),
+		)
+		return withCGoError(&apiStatus)
+	})
+}
+
+// slogHandler adapts a Logger to the standard library's slog.Handler
+// interface, so extension authors who already have slog-based code can
+// route it through the TEN log sink with slog.New(ten.NewSlogHandler(logger, nil)).
+type slogHandler struct {
+	logger *logger
+	level  slog.Leveler
+}
+
+// NewSlogHandler wraps a TEN Logger as a slog.Handler.
+func NewSlogHandler(l Logger, level slog.Leveler) slog.Handler {
+	impl, ok := l.(*logger)
+	if !ok {
+		// Fall back to a fresh logger sharing no bridge; callers should
+		// always pass the Logger returned by TenEnv.Logger().
+		impl = &logger{}
+	}
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	return &slogHandler{logger: impl, level: level}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *slogHandler) Handle(_ context.Context, record slog.Record) error {
+	kv := make([]any, 0, record.NumAttrs()*2)
+	record.Attrs(func(a slog.Attr) bool {
+		kv = append(kv, a.Key, a.Value.Any())
+		return true
+	})
+	h.logger.log(levelFromSlog(record.Level), record.Message, kv...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	kv := make([]any, 0, len(attrs)*2)
+	for _, a := range attrs {
+		kv = append(kv, a.Key, a.Value.Any())
+	}
+	return &slogHandler{logger: h.logger.With(kv...).(*logger), level: h.level}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	return &slogHandler{logger: h.logger.WithGroup(name).(*logger), level: h.level}
+}
+
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelWarn:
+		return slog.LevelWarn
+	case LogLevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func levelFromSlog(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return LogLevelDebug
+	case level < slog.LevelWarn:
+		return LogLevelInfo
+	case level < slog.LevelError:
+		return LogLevelWarn
+	default:
+		return LogLevelError
+	}
+}
+
+// formatLogRecord renders a slog.Record as "msg key=value key=value ...",
+// the line format understood by the underlying C log sink.
+func formatLogRecord(record slog.Record, groups []string) string {
+	line := record.Message
+	prefix := ""
+	for _, g := range groups {
+		prefix += g + "."
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		line += " " + prefix + a.Key + "=" + a.Value.String()
+		return true
+	})
+	return line
+}
+
+// Logger returns the structured Logger for this ten_env, enriched with
+// graph_id and extension_name. LogInfo/LogError are unchanged and do not go
+// through this Logger.
+func (p *tenEnv) Logger() Logger {
+	if p.logger == nil {
+		p.logger = newLogger(p.getCPtr(), p.graphID, p.extensionName)
+	}
+	return p.logger
+}
+
+// loggerForCmd returns a Logger pre-enriched with cmd's cmd_id, for use by
+// OnCmd handlers and anywhere else a Cmd is already in scope.
+func (p *tenEnv) loggerForCmd(cmd Cmd) Logger {
+	return p.Logger().(interface{ withCmdScope(Cmd) Logger }).withCmdScope(cmd)
+}
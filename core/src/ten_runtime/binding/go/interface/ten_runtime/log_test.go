@@ -0,0 +1,91 @@
+//
+// Copyright © 2025 Agora
+// This file is part of TEN Framework, an open source project.
+// Licensed under the Apache License, Version 2.0, with certain conditions.
+// Refer to the "LICENSE" file in the root directory for more information.
+//
+
+package ten_runtime
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// These exercise the pure record-formatting and level-mapping logic behind
+// Logger/NewSlogHandler, confirming records render as the
+// "msg key=value key=value ..." line handed to the C log sink, without
+// needing a live ten_env bridge.
+
+func TestFormatLogRecord(t *testing.T) {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "worker started", 0)
+	record.Add("channel_name", "abc123", "graph_id", "g-1")
+
+	got := formatLogRecord(record, nil)
+	want := "worker started channel_name=abc123 graph_id=g-1"
+	if got != want {
+		t.Fatalf("formatLogRecord() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatLogRecord_WithGroups(t *testing.T) {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "tick", 0)
+	record.Add("count", 3)
+
+	got := formatLogRecord(record, []string{"poller", "graphs"})
+	want := "tick poller.graphs.count=3"
+	if got != want {
+		t.Fatalf("formatLogRecord() = %q, want %q", got, want)
+	}
+}
+
+func TestSlogLevelRoundTrip(t *testing.T) {
+	cases := []struct {
+		level LogLevel
+		slog  slog.Level
+	}{
+		{LogLevelDebug, slog.LevelDebug},
+		{LogLevelInfo, slog.LevelInfo},
+		{LogLevelWarn, slog.LevelWarn},
+		{LogLevelError, slog.LevelError},
+	}
+
+	for _, tc := range cases {
+		if got := slogLevel(tc.level); got != tc.slog {
+			t.Errorf("slogLevel(%v) = %v, want %v", tc.level, got, tc.slog)
+		}
+		if got := levelFromSlog(tc.slog); got != tc.level {
+			t.Errorf("levelFromSlog(%v) = %v, want %v", tc.slog, got, tc.level)
+		}
+	}
+}
+
+func TestLoggerWithAppendsWithoutMutatingParent(t *testing.T) {
+	base := &logger{graphID: "g-1", extensionName: "ext-a"}
+
+	child := base.With("request_id", "r-1")
+
+	if len(base.attrs) != 0 {
+		t.Fatalf("With() must not mutate the receiver's attrs")
+	}
+	childAttrs := child.(*logger).attrs
+	if len(childAttrs) != 2 || childAttrs[0] != "request_id" || childAttrs[1] != "r-1" {
+		t.Fatalf("child logger attrs = %v, want [request_id r-1]", childAttrs)
+	}
+}
+
+func TestLoggerEnrichment(t *testing.T) {
+	l := &logger{graphID: "g-1", extensionName: "ext-a", cmdID: "c-1"}
+
+	kv := l.enrichment()
+	want := []any{"graph_id", "g-1", "extension_name", "ext-a", "cmd_id", "c-1"}
+	if len(kv) != len(want) {
+		t.Fatalf("enrichment() = %v, want %v", kv, want)
+	}
+	for i := range want {
+		if kv[i] != want[i] {
+			t.Fatalf("enrichment()[%d] = %v, want %v", i, kv[i], want[i])
+		}
+	}
+}
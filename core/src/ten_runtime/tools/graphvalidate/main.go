@@ -0,0 +1,42 @@
+//
+// Copyright © 2025 Agora
+// This file is part of TEN Framework, an open source project.
+// Licensed under the Apache License, Version 2.0, with certain conditions.
+// Refer to the "LICENSE" file in the root directory for more information.
+//
+
+// Command graphvalidate loads one or more graph spec files (YAML or JSON,
+// with $include/$ref fragments resolved) and validates them against the
+// graph schema, so authoring mistakes are caught at build time instead of
+// surfacing as a runtime panic from SetGraphFromJSONBytes.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	ten "ten_framework/ten_runtime"
+)
+
+func main() {
+	flag.Parse()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		fmt.Println("Usage: graphvalidate <graph-spec-file>...")
+		os.Exit(2)
+	}
+
+	exitCode := 0
+	for _, path := range paths {
+		if _, err := ten.LoadGraphSpecFromFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			exitCode = 1
+			continue
+		}
+		fmt.Printf("%s: ok\n", path)
+	}
+
+	os.Exit(exitCode)
+}
@@ -8,6 +8,7 @@
 package test_extension_1
 
 import (
+	"context"
 	"encoding/json"
 
 	ten "ten_framework/ten_runtime"
@@ -22,17 +23,6 @@ type testExtension1 struct {
 }
 
 func (ext *testExtension1) OnStart(tenEnv ten.TenEnv) {
-	// Start a new graph
-	startGraphCmd, _ := ten.NewStartGraphCmd()
-
-	// The destination of the 'start_graph' command is the current app,
-	// using "" to represent current app.
-	startGraphCmd.SetDests(ten.Loc{
-		AppURI:        ten.Ptr(""),
-		GraphID:       nil,
-		ExtensionName: nil,
-	})
-
 	// The new graph contains 3 extensions.
 	graphJSON := `{
 		"nodes": [{
@@ -79,11 +69,23 @@ func (ext *testExtension1) OnStart(tenEnv ten.TenEnv) {
 		}]
 	}`
 
-	err := startGraphCmd.SetGraphFromJSONBytes([]byte(graphJSON))
+	// Run the admission chain before building the command, rather than
+	// calling ten.NewStartGraphCmd() directly, so a registered admission
+	// hook gets a chance to deny or rewrite this graph the same way it
+	// would for a graph loaded from a GraphSpec file.
+	startGraphCmd, err := ten.NewStartGraphCmdWithAdmission(context.Background(), []byte(graphJSON), "test_extension_1")
 	if err != nil {
-		panic("Failed to set graph JSON: " + err.Error())
+		panic("Failed to build start_graph command: " + err.Error())
 	}
 
+	// The destination of the 'start_graph' command is the current app,
+	// using "" to represent current app.
+	startGraphCmd.SetDests(ten.Loc{
+		AppURI:        ten.Ptr(""),
+		GraphID:       nil,
+		ExtensionName: nil,
+	})
+
 	tenEnv.SendCmd(
 		startGraphCmd,
 		func(tenEnv ten.TenEnv, cmdResult ten.CmdResult, err error) {
@@ -133,14 +135,19 @@ func (ext *testExtension1) OnStart(tenEnv ten.TenEnv) {
 func (ext *testExtension1) OnStop(tenEnv ten.TenEnv) {
 	tenEnv.LogInfo("on_stop")
 
-	// Stop the started graph
-	stopGraphCmd, _ := ten.NewStopGraphCmd()
+	// Stop the started graph, again running it through the admission
+	// chain rather than calling ten.NewStopGraphCmd() directly.
+	stopGraphCmd, err := ten.NewStopGraphCmdWithAdmission(context.Background(), ext.newGraphID, "test_extension_1")
+	if err != nil {
+		tenEnv.LogError("Failed to build stop_graph command: " + err.Error())
+		tenEnv.OnStopDone()
+		return
+	}
 	stopGraphCmd.SetDests(ten.Loc{
 		AppURI:        ten.Ptr(""),
 		GraphID:       nil,
 		ExtensionName: nil,
 	})
-	stopGraphCmd.SetGraphID(ext.newGraphID)
 
 	tenEnv.SendCmd(
 		stopGraphCmd,
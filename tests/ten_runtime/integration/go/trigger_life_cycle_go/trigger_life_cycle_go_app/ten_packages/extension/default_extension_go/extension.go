@@ -84,7 +84,61 @@ func (p *mainExtension) OnInit(
 								)
 							}
 
-							tenEnv.OnInitDone()
+							// Pause the biz extension, then resume it, to
+							// exercise the "pause"/"resume" stages.
+							pauseCmd, _ := ten.NewTriggerLifeCycleCmd()
+							pauseCmd.SetStage("pause")
+							pauseCmd.SetDests(ten.Loc{
+								AppURI:        ten.Ptr(""),
+								GraphID:       ten.Ptr(""),
+								ExtensionName: ten.Ptr("biz"),
+							})
+
+							tenEnv.SendCmd(
+								pauseCmd,
+								func(te ten.TenEnv, cr ten.CmdResult, err error) {
+									if err != nil {
+										panic("Failed to send cmd: " + err.Error())
+									}
+
+									resumeCmd, _ := ten.NewTriggerLifeCycleCmd()
+									resumeCmd.SetStage("resume")
+									resumeCmd.SetDests(ten.Loc{
+										AppURI:        ten.Ptr(""),
+										GraphID:       ten.Ptr(""),
+										ExtensionName: ten.Ptr("biz"),
+									})
+
+									tenEnv.SendCmd(
+										resumeCmd,
+										func(te ten.TenEnv, cr ten.CmdResult, err error) {
+											if err != nil {
+												panic("Failed to send cmd: " + err.Error())
+											}
+
+											cmd, _ := ten.NewCmd("check_pause_resume")
+											tenEnv.SendCmd(
+												cmd,
+												func(te ten.TenEnv, cr ten.CmdResult, err error) {
+													if err != nil {
+														panic("Failed to send cmd: " + err.Error())
+													}
+
+													paused, _ := cr.GetPropertyBool("paused")
+													resumed, _ := cr.GetPropertyBool("resumed")
+													if paused || !resumed {
+														panic(
+															"Biz extension should be resumed, not paused, after the pause/resume trigger sequence",
+														)
+													}
+
+													tenEnv.OnInitDone()
+												},
+											)
+										},
+									)
+								},
+							)
 						},
 					)
 				},
@@ -167,6 +221,8 @@ type bizExtension struct {
 
 	started bool
 	stopped bool
+	paused  bool
+	resumed bool
 }
 
 func (p *bizExtension) OnStart(
@@ -185,6 +241,16 @@ func (p *bizExtension) OnStop(
 	tenEnv.OnStopDone()
 }
 
+func (p *bizExtension) OnPause(tenEnv ten.TenEnv) {
+	p.paused = true
+	p.resumed = false
+}
+
+func (p *bizExtension) OnResume(tenEnv ten.TenEnv) {
+	p.resumed = true
+	p.paused = false
+}
+
 func (p *bizExtension) OnCmd(
 	tenEnv ten.TenEnv,
 	cmd ten.Cmd,
@@ -199,6 +265,11 @@ func (p *bizExtension) OnCmd(
 		cmdResult, _ := ten.NewCmdResult(ten.StatusCodeOk, cmd)
 		cmdResult.SetProperty("stopped", p.stopped)
 		tenEnv.ReturnResult(cmdResult, nil)
+	case "check_pause_resume":
+		cmdResult, _ := ten.NewCmdResult(ten.StatusCodeOk, cmd)
+		cmdResult.SetProperty("paused", p.paused)
+		cmdResult.SetProperty("resumed", p.resumed)
+		tenEnv.ReturnResult(cmdResult, nil)
 	default:
 		cmdResult, _ := ten.NewCmdResult(ten.StatusCodeError, cmd)
 		cmdResult.SetPropertyString("detail", "unknown command")